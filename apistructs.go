@@ -48,50 +48,48 @@ type CalendarEvent struct {
 	} `xml:"description"`
 }
 
-func getResourceAttrVal(c CalendarEvent, key string) (val string, found bool) {
-	for _, attribute := range c.Description.Attributes {
-		if key == attribute.AttrID {
-			return attribute.Text, true
-		}
-	}
-	return "", false
+//Rowset Is a struct representing a response from the "veranstaltungenSuche" api
+type Rowset struct {
+	XMLName xml.Name    `xml:"rowset"`
+	Text    string      `xml:",chardata"`
+	Row     []RowsetRow `xml:"row"`
 }
 
-func filterAttrRDM(rdm RDM, key string, typeID string) RDM {
-	rdmRet := rdm
-	rdmRet.Resource.Content.ResourceGroup.Content.Events = []CalendarEvent{}
-	for _, event := range rdm.Resource.Content.ResourceGroup.Content.Events {
-		if val, found := getResourceAttrVal(event, key); found && val == typeID { // A -> Abhaltung
-			rdmRet.Resource.Content.ResourceGroup.Content.Events = append(rdmRet.Resource.Content.ResourceGroup.Content.Events, event)
-		}
-	}
-	return rdmRet
+// RowsetRow is a single course of a "veranstaltungenSuche" response
+type RowsetRow struct {
+	Text                   string `xml:",chardata"`
+	StpSpNr                string `xml:"stp_sp_nr"`
+	StpLvNr                string `xml:"stp_lv_nr"`
+	StpSpTitel             string `xml:"stp_sp_titel"`
+	DauerInfo              string `xml:"dauer_info"`
+	StpSpSst               string `xml:"stp_sp_sst"`
+	StpLvArtName           string `xml:"stp_lv_art_name"`
+	StpLvArtKurz           string `xml:"stp_lv_art_kurz"`
+	SjName                 string `xml:"sj_name"`
+	Semester               string `xml:"semester"`
+	SemesterName           string `xml:"semester_name"`
+	SemesterID             string `xml:"semester_id"`
+	OrgNrBetreut           string `xml:"org_nr_betreut"`
+	OrgNameBetreut         string `xml:"org_name_betreut"`
+	OrgKennungBetreut      string `xml:"org_kennung_betreut"`
+	VortragendeMitwirkende struct {
+		Text   string `xml:",chardata"`
+		Isnull string `xml:"isnull,attr"`
+	} `xml:"vortragende_mitwirkende"`
 }
 
-//Rowset Is a struct representing a response from the "veranstaltungenSuche" api
-type Rowset struct {
+//OrgTree Is a struct representing a response from the "orgBaum" api
+type OrgTree struct {
 	XMLName xml.Name `xml:"rowset"`
 	Text    string   `xml:",chardata"`
 	Row     []struct {
-		Text                   string `xml:",chardata"`
-		StpSpNr                string `xml:"stp_sp_nr"`
-		StpLvNr                string `xml:"stp_lv_nr"`
-		StpSpTitel             string `xml:"stp_sp_titel"`
-		DauerInfo              string `xml:"dauer_info"`
-		StpSpSst               string `xml:"stp_sp_sst"`
-		StpLvArtName           string `xml:"stp_lv_art_name"`
-		StpLvArtKurz           string `xml:"stp_lv_art_kurz"`
-		SjName                 string `xml:"sj_name"`
-		Semester               string `xml:"semester"`
-		SemesterName           string `xml:"semester_name"`
-		SemesterID             string `xml:"semester_id"`
-		OrgNrBetreut           string `xml:"org_nr_betreut"`
-		OrgNameBetreut         string `xml:"org_name_betreut"`
-		OrgKennungBetreut      string `xml:"org_kennung_betreut"`
-		VortragendeMitwirkende struct {
-			Text   string `xml:",chardata"`
-			Isnull string `xml:"isnull,attr"`
-		} `xml:"vortragende_mitwirkende"`
+		Text          string `xml:",chardata"`
+		Nr            string `xml:"nr"`
+		Parent        string `xml:"parent"`
+		Kennung       string `xml:"kennung"`
+		OrgGruppeName string `xml:"org_gruppe_name"`
+		NameDe        string `xml:"name_de"`
+		NameEn        string `xml:"name_en"`
 	} `xml:"row"`
 }
 
@@ -0,0 +1,159 @@
+package campusonline
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+)
+
+// Cache stores raw TUMonline responses by the url they were requested with. Urls are stored without tokens.
+type Cache interface {
+	// Get returns the response stored for key. Unreadable entries are reported as missing.
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, res *CachedResponse) error
+}
+
+// CachedResponse is a response body with the time it was fetched and the validators TUMonline sent with it
+type CachedResponse struct {
+	Body         []byte    `json:"-"`
+	Fetched      time.Time `json:"fetched"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+}
+
+// WithCache makes the client keep responses in cache and serve them without asking TUMonline for ttl after they were
+// fetched. Older responses are revalidated with a conditional request, so a ttl of 0 still saves bandwidth and
+// parsing if TUMonline sends validators. Only requests whose reply is parsed as a whole are cached, streams are always
+// fetched.
+func WithCache(cache Cache, ttl time.Duration) Option {
+	return func(c *CampusOnline) {
+		c.responses = cache
+		c.responseTTL = ttl
+	}
+}
+
+// NopCache doesn't store anything. It's the default.
+type NopCache struct{}
+
+func (NopCache) Get(string) (*CachedResponse, bool) {
+	return nil, false
+}
+
+func (NopCache) Set(string, *CachedResponse) error {
+	return nil
+}
+
+// MemoryCache keeps responses in memory until the process exits
+type MemoryCache struct {
+	cache *ristretto.Cache
+}
+
+// NewMemoryCache returns a cache holding responses of up to maxBytes in total. The least valuable responses are
+// evicted first.
+func NewMemoryCache(maxBytes int64) (*MemoryCache, error) {
+	cache, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 1e5,
+		MaxCost:     maxBytes,
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryCache{cache: cache}, nil
+}
+
+func (m *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	cached, found := m.cache.Get(key)
+	if !found {
+		return nil, false
+	}
+	return cached.(*CachedResponse), true
+}
+
+func (m *MemoryCache) Set(key string, res *CachedResponse) error {
+	m.cache.Set(key, res, int64(len(res.Body)))
+	m.cache.Wait() // make the response visible to the next Get
+	return nil
+}
+
+// FileCache keeps responses in files, so they survive restarts
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache returns a cache storing responses in dir, which is created if necessary. Responses can contain personal
+// data, so the files are only readable by the current user.
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileCache{dir: dir}, nil
+}
+
+// fileCacheEntry is the first line of a cache file, the body follows after it
+type fileCacheEntry struct {
+	Key string `json:"key"`
+	CachedResponse
+}
+
+func (f *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".cache")
+}
+
+func (f *FileCache) Get(key string) (*CachedResponse, bool) {
+	file, err := os.Open(f.path(key))
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	header, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, false
+	}
+	var entry fileCacheEntry
+	if err := json.Unmarshal(header, &entry); err != nil || entry.Key != key {
+		return nil, false
+	}
+	if entry.Body, err = ioutil.ReadAll(r); err != nil {
+		return nil, false
+	}
+	return &entry.CachedResponse, true
+}
+
+// Set writes the response to a temporary file first, so concurrent readers never see partial responses
+func (f *FileCache) Set(key string, res *CachedResponse) error {
+	header, err := json.Marshal(fileCacheEntry{Key: key, CachedResponse: *res})
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(f.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+	_, err = tmp.Write(append(header, '\n'))
+	if err == nil {
+		_, err = tmp.Write(res.Body)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+// cacheKey returns the url of u without token
+func (c *CampusOnline) cacheKey(u apiURL) string {
+	return c.buildURL(u, "")
+}
@@ -0,0 +1,173 @@
+package campusonline
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+func TestCaches(t *testing.T) {
+	memory, err := NewMemoryCache(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := NewFileCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched := time.Date(2021, 10, 18, 12, 0, 0, 0, time.UTC)
+	for name, cache := range map[string]Cache{"memory": memory, "file": file} {
+		if _, found := cache.Get("key"); found {
+			t.Errorf("%s: expected empty cache", name)
+		}
+		res := &CachedResponse{Body: []byte("<xml>\n</xml>"), Fetched: fetched, ETag: `"1"`, LastModified: "yesterday"}
+		if err := cache.Set("key", res); err != nil {
+			t.Fatal(err)
+		}
+		cached, found := cache.Get("key")
+		if !found || string(cached.Body) != string(res.Body) || !cached.Fetched.Equal(fetched) ||
+			cached.ETag != res.ETag || cached.LastModified != res.LastModified {
+			t.Errorf("%s: expected %+v, got %+v", name, res, cached)
+		}
+		if _, found := cache.Get("other"); found {
+			t.Errorf("%s: expected other key to be missing", name)
+		}
+	}
+	if err := (NopCache{}).Set("key", &CachedResponse{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := (NopCache{}).Get("key"); found {
+		t.Errorf("expected nop cache to be empty")
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, server := newTestClient(t, WithCache(cache, time.Hour))
+	if _, err := c.ExportCourse(950000001); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ExportCourse(950000001); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.Requests()); n != 1 {
+		t.Errorf("expected the second export to be served from the cache, got %d requests", n)
+	}
+	key := c.cacheKey(wsURL(courseExportDN, 950000001))
+	if strings.Contains(key, campusonlinetest.Token) {
+		t.Errorf("expected cache key without token, got %s", key)
+	}
+
+	// a new client with the same directory keeps using the responses
+	reopened, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := New(campusonlinetest.Token, campusonlinetest.BasicToken, WithBaseURL(server.BaseURL(), server.BasicBaseURL()),
+		WithCache(reopened, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c2.ExportCourse(950000001); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.Requests()); n != 1 {
+		t.Errorf("expected the cache to survive restarts, got %d requests", n)
+	}
+
+	// expired responses are fetched again
+	cached, _ := cache.Get(key)
+	cached.Fetched = time.Now().Add(-time.Hour * 2)
+	if err := cache.Set(key, cached); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ExportCourse(950000001); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.Requests()); n != 2 {
+		t.Errorf("expected expired response to be fetched again, got %d requests", n)
+	}
+}
+
+// statusRecorder records the status of every response passing through it
+type statusRecorder struct {
+	statuses []int
+}
+
+func (s *statusRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil {
+		s.statuses = append(s.statuses, resp.StatusCode)
+	}
+	return resp, err
+}
+
+func TestConditionalRequests(t *testing.T) {
+	cache, err := NewMemoryCache(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &statusRecorder{}
+	c, server := newTestClient(t, WithCache(cache, 0), WithTransport(recorder))
+	first, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Sort()
+	second, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.statuses) != 2 || recorder.statuses[1] != http.StatusNotModified {
+		t.Fatalf("expected a full and a not modified response, got %v", recorder.statuses)
+	}
+	if len(second.Vcalendar.Events) != 20 {
+		t.Errorf("expected the cached calendar, got %d events", len(second.Vcalendar.Events))
+	}
+	if second.Vcalendar.Events[0].Uid != "884100001@tumonline" {
+		t.Errorf("expected cached result to be unaffected by sorting the first result, got %s", second.Vcalendar.Events[0].Uid)
+	}
+
+	// changed documents are fetched and parsed again
+	server.SetXCalOrg(CsOrgId, campusonlinetest.Fixture("xcal_53599.xml"))
+	third, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.statuses[2] != http.StatusOK || len(third.Vcalendar.Events) == 20 {
+		t.Errorf("expected the changed calendar, got status %d and %d events", recorder.statuses[2], len(third.Vcalendar.Events))
+	}
+}
+
+func TestCachedResultsAreCopies(t *testing.T) {
+	cache, err := NewMemoryCache(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := newTestClient(t, WithCache(cache, 0))
+	first, err := c.ExportCourse(950000001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Course.Contacts.Person) == 0 || len(first.Course.Contacts.Person[0].Role) == 0 {
+		t.Fatal("expected contacts with roles")
+	}
+	family := first.Course.Contacts.Person[0].Name.Family
+	first.Course.Contacts.Person[0].Name.Family = "Changed"
+	first.Course.Contacts.Person[0].Role[0].Text = "Changed"
+	second, err := c.ExportCourse(950000001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	person := second.Course.Contacts.Person[0]
+	if person.Name.Family != family || person.Role[0].Text == "Changed" {
+		t.Errorf("expected cached course to be unaffected by changing the first result, got %+v", person)
+	}
+}
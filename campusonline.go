@@ -1,29 +1,118 @@
 package campusonline
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"github.com/dgraph-io/ristretto"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	baseURL        = "https://campus.tum.de/tumonlinej/ws/webservice_v1.0/"
-	basicBaseURL   = "https://campus.tum.de/tumonline/wbservicesbasic."
-	roomDN         = "/rdm/room/schedule/xml?token=%s&timeMode=absolute&roomID=%d&buildingCode=&fromDate=%s&untilDate=%s"
-	courseSearchDN = "veranstaltungenSuche?pToken=%s&pSuche=%s&pSemester=%s"
-	courseExportDN = "/cdm/course/xml?token=%s&courseID=%d"
+	defaultBaseURL      = "https://campus.tum.de/tumonlinej/ws/webservice_v1.0/"
+	defaultBasicBaseURL = "https://campus.tum.de/tumonline/wbservicesbasic."
+	roomDN              = "/rdm/room/schedule/xml?token=%s&timeMode=absolute&roomID=%d&buildingCode=&fromDate=%s&untilDate=%s"
+	courseSearchDN      = "veranstaltungenSuche?pToken=%s&pSuche=%s&pSemester=%s"
+	courseExportDN      = "/cdm/course/xml?token=%s&courseID=%d"
+	courseExportLangDN  = "/cdm/course/xml?token=%s&courseID=%d&language=%s"
 )
 
+const defaultMaxConcurrency = 4
+
 type CampusOnline struct {
-	token      string
-	basicToken string
-	cache      *ristretto.Cache
+	tokens           TokenProvider
+	basicTokens      TokenProvider
+	knownTokens      *sync.Map        // all tokens used so far, for redaction
+	cache            *ristretto.Cache // parsed results
+	responses        Cache            // raw responses
+	responseTTL      time.Duration
+	client           *http.Client
+	logger           Logger
+	baseURL          string
+	basicBaseURL     string
+	chunkSize        time.Duration
+	maxConcurrency   int
+	slots            chan struct{} // one per request in flight, created by New with maxConcurrency slots
+	mainContactRoles []Role
+	languages        []Language
+	limiter          *rateLimiter // nil if requests aren't limited
+}
+
+// Option configures optional behaviour of a CampusOnline client
+type Option func(*CampusOnline)
+
+// WithBaseURL points the client to another TUMonline instance, e.g. a test server.
+// baseURL is the prefix of the webservice_v1.0 api, basicBaseURL the prefix of the wbservicesbasic api. Empty urls
+// keep the default of the api.
+func WithBaseURL(baseURL string, basicBaseURL string) Option {
+	return func(c *CampusOnline) {
+		if baseURL != "" {
+			c.baseURL = baseURL
+		}
+		if basicBaseURL != "" {
+			c.basicBaseURL = basicBaseURL
+		}
+	}
+}
+
+// WithHTTPClient makes the client send all requests with client instead of http.DefaultClient
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *CampusOnline) {
+		c.client = client
+	}
+}
+
+// WithTransport makes the client send all requests through transport, e.g. to record or replay them
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *CampusOnline) {
+		c.client = &http.Client{Transport: transport}
+	}
+}
+
+// WithChunkSize makes the client split long date ranges into requests spanning at most size (rounded to whole days).
+// A size of 0 disables chunking.
+func WithChunkSize(size time.Duration) Option {
+	return func(c *CampusOnline) {
+		c.chunkSize = size
+	}
 }
 
-func New(token string, basicToken string) (*CampusOnline, error) {
+// WithMaxConcurrency limits the number of requests the client sends to TUMonline in parallel. The limit applies to the
+// client as a whole, across all calls made concurrently.
+func WithMaxConcurrency(n int) Option {
+	return func(c *CampusOnline) {
+		if n > 0 {
+			c.maxConcurrency = n
+		}
+	}
+}
+
+// parallel calls fn for 0..n-1 concurrently and returns their errors by index. The requests made by fn are limited by
+// send, so nested calls don't multiply the number of requests in flight.
+func (c *CampusOnline) parallel(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+func New(token string, basicToken string, opts ...Option) (*CampusOnline, error) {
 	cache, err := ristretto.NewCache(&ristretto.Config{
 		NumCounters: 1e7,     // number of keys to track frequency of (10M).
 		MaxCost:     1 << 30, // maximum cost of cache (1GB).
@@ -32,7 +121,26 @@ func New(token string, basicToken string) (*CampusOnline, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &CampusOnline{token: token, basicToken: basicToken, cache: cache}, nil
+	c := &CampusOnline{
+		tokens:           StaticToken(token),
+		basicTokens:      StaticToken(basicToken),
+		knownTokens:      &sync.Map{},
+		cache:            cache,
+		responses:        NopCache{},
+		client:           http.DefaultClient,
+		logger:           nopLogger{},
+		baseURL:          defaultBaseURL,
+		basicBaseURL:     defaultBasicBaseURL,
+		maxConcurrency:   defaultMaxConcurrency,
+		mainContactRoles: defaultMainContactRoles,
+		languages:        []Language{German},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.slots = make(chan struct{}, c.maxConcurrency)
+	c.logger = redactingLogger{l: c.logger, redact: c.redact}
+	return c, nil
 }
 
 type Room struct {
@@ -46,6 +154,9 @@ type Course struct {
 	Events   []Event         `json:"events"`
 	Contacts []ContactPerson `json:"contacts"`
 	Import   bool            `json:"import"`
+	// Titles and Descriptions are set by LoadCourseContacts in the languages configured with WithLanguages
+	Titles       LocalizedText `json:"titles,omitempty"`
+	Descriptions LocalizedText `json:"descriptions,omitempty"`
 }
 
 type Event struct {
@@ -57,30 +168,363 @@ type Event struct {
 	Comment  string    `json:"comment"`
 	Import   bool      `json:"import"`
 	EventID  string    `json:"event_id"`
+	// LectureFree is set by MarkLectureFree for events on public holidays or during lecture-free breaks
+	LectureFree bool `json:"lecture_free"`
+}
+
+// ExportCourse returns the course export of the course with the given id
+func (c *CampusOnline) ExportCourse(id int) (CDM, error) {
+	return c.exportCourseByID(id)
 }
 
 func (c *CampusOnline) exportCourseByID(id int) (CDM, error) {
-	url := baseURL + fmt.Sprintf(courseExportDN, c.token, id)
-	response, err := http.Get(url)
+	var result CDM
+	err := c.getXML(wsURL(courseExportDN, id), &result)
 	if err != nil {
 		return CDM{}, err
 	}
-	body, err := ioutil.ReadAll(response.Body)
+	return result, nil
+}
+
+// GetCourses exports the courses in parallel like ExportCourse. Failing exports don't affect the others: courses that
+// could be exported are returned by id, the errors of the others in the second map. Exports are served from the cache
+// configured with WithCache and limited by WithRateLimit like all requests.
+func (c *CampusOnline) GetCourses(ids []int) (map[int]CDM, map[int]error) {
+	var unique []int
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	exports := make([]CDM, len(unique))
+	errs := c.parallel(len(unique), func(i int) error {
+		var err error
+		exports[i], err = c.exportCourseByID(unique[i])
+		return err
+	})
+	res := map[int]CDM{}
+	failed := map[int]error{}
+	for i, id := range unique {
+		if errs[i] != nil {
+			failed[id] = errs[i]
+		} else {
+			res[id] = exports[i]
+		}
+	}
+	return res, failed
+}
+
+// apiURL is the url of a TUMonline request. The token is only filled in when the request is sent.
+type apiURL struct {
+	basic  bool   // wbservicesbasic instead of webservice_v1.0
+	format string // the token is the first verb
+	args   []interface{}
+}
+
+func wsURL(format string, args ...interface{}) apiURL {
+	return apiURL{format: format, args: args}
+}
+
+func basicURL(format string, args ...interface{}) apiURL {
+	return apiURL{basic: true, format: format, args: args}
+}
+
+func (c *CampusOnline) buildURL(u apiURL, token string) string {
+	base := c.baseURL
+	if u.basic {
+		base = c.basicBaseURL
+	}
+	return base + fmt.Sprintf(u.format, append([]interface{}{token}, u.args...)...)
+}
+
+// getXML requests u and unmarshals the xml reply into v. If the client caches responses, parsed replies are kept in
+// memory too, so replies served from the cache or confirmed unchanged by TUMonline aren't parsed again.
+func (c *CampusOnline) getXML(u apiURL, v interface{}) error {
+	res, err := c.getBody(u)
 	if err != nil {
-		return CDM{}, err
+		return err
 	}
-	var result CDM
-	err = xml.Unmarshal(body, &result)
+	if _, nop := c.responses.(NopCache); nop {
+		return xml.Unmarshal(res.Body, v)
+	}
+	key := "parsed/" + c.cacheKey(u)
+	sum := sha256.Sum256(res.Body)
+	if cached, found := c.cache.Get(key); found {
+		if p := cached.(parsedResponse); p.sum == sum && p.value.Type() == reflect.TypeOf(v).Elem() {
+			reflect.ValueOf(v).Elem().Set(deepCopy(p.value))
+			return nil
+		}
+	}
+	if err := xml.Unmarshal(res.Body, v); err != nil {
+		return err
+	}
+	c.cache.Set(key, parsedResponse{sum: sum, value: deepCopy(reflect.ValueOf(v).Elem())}, int64(len(res.Body)))
+	c.cache.Wait()
+	return nil
+}
+
+// parsedResponse is the result of unmarshalling a response body with the given hash
+type parsedResponse struct {
+	sum   [sha256.Size]byte
+	value reflect.Value
+}
+
+// deepCopy returns a copy of v sharing no pointers, slices or maps with it, so callers can modify parsed replies
+// without changing the cached ones. Unexported fields are copied shallowly.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Elem().Type())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	}
+	return v
+}
+
+// getBody returns the reply to u. Replies are taken from the client's cache while they are younger than its ttl.
+// Older replies are revalidated with a conditional request if TUMonline sent an ETag or Last-Modified header.
+func (c *CampusOnline) getBody(u apiURL) (*CachedResponse, error) {
+	key := c.cacheKey(u)
+	cached, found := c.responses.Get(key)
+	if found && time.Since(cached.Fetched) < c.responseTTL {
+		c.logger.Debug("serving TUMonline response from cache", "url", key)
+		return cached, nil
+	}
+	if !found {
+		cached = nil
+	}
+	resp, err := c.getResponse(u, cached)
 	if err != nil {
-		return CDM{}, err
+		return nil, err
 	}
-	return result, nil
+	defer resp.Body.Close()
+	var res CachedResponse
+	if resp.StatusCode == http.StatusNotModified {
+		c.logger.Debug("TUMonline response not modified", "url", key)
+		res = *cached
+		res.Fetched = time.Now()
+	} else {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		res = CachedResponse{
+			Body:         body,
+			Fetched:      time.Now(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+	}
+	if err := c.responses.Set(key, &res); err != nil {
+		c.logger.Warn("caching TUMonline response failed", "url", key, "error", err)
+	}
+	return &res, nil
+}
+
+// getStream requests u and returns the reply body which must be closed by the caller
+func (c *CampusOnline) getStream(u apiURL) (io.ReadCloser, error) {
+	resp, err := c.getResponse(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// getResponse requests u, conditionally if cached isn't nil. If TUMonline rejects the token, the request is retried
+// once with a refreshed token.
+func (c *CampusOnline) getResponse(u apiURL, cached *CachedResponse) (*http.Response, error) {
+	token, err := c.currentToken(u.basic)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(c.buildURL(u, token), cached)
+	if !errors.Is(err, ErrInvalidToken) {
+		return resp, err
+	}
+	refreshed, refreshErr := c.refreshToken(u.basic)
+	if refreshErr != nil || refreshed == token {
+		return nil, err
+	}
+	c.logger.Info("retrying TUMonline request with refreshed token")
+	return c.send(c.buildURL(u, refreshed), cached)
+}
+
+// send requests url and returns the response if its status is 200 OK, or 304 Not Modified for a request made
+// conditional on the validators of cached. The body must be closed by the caller.
+func (c *CampusOnline) send(url string, cached *CachedResponse) (*http.Response, error) {
+	c.logger.Debug("requesting TUMonline", "url", url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, c.redactError(err)
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached != nil && cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+	// the slot is taken first, so the request is sent when it is due and not later than the rate limit assumes
+	c.slots <- struct{}{}
+	c.limiter.wait()
+	resp, err := c.client.Do(req)
+	<-c.slots
+	if err != nil {
+		err = c.redactError(err)
+		c.logger.Warn("TUMonline request failed", "url", url, "error", err)
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && req.Header.Get("If-None-Match")+req.Header.Get("If-Modified-Since") != "" {
+		return resp, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		c.logger.Warn("unexpected TUMonline response", "url", url, "status", resp.StatusCode)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	// TUMonline may report errors, including invalid tokens, with an error document instead of a status code
+	body := bufio.NewReader(resp.Body)
+	if head, _ := body.Peek(512); isErrorDocument(head) {
+		defer resp.Body.Close()
+		var doc errorDocument
+		if err := xml.NewDecoder(io.LimitReader(body, maxErrorDocument)).Decode(&doc); err != nil {
+			return nil, c.redactError(err)
+		}
+		msg := c.redact(doc.message())
+		if isTokenError(msg) {
+			c.logger.Warn("TUMonline rejected the token", "url", url)
+			return nil, ErrInvalidToken
+		}
+		c.logger.Warn("TUMonline replied with an error", "url", url, "message", msg)
+		return nil, &DocumentError{Message: msg}
+	}
+	resp.Body = bufferedBody{Reader: body, Closer: resp.Body}
+	return resp, nil
+}
+
+// isErrorDocument reports whether the xml document starting with head has an error root element
+func isErrorDocument(head []byte) bool {
+	head = bytes.TrimSpace(head)
+	if bytes.HasPrefix(head, []byte("<?xml")) {
+		end := bytes.Index(head, []byte("?>"))
+		if end < 0 {
+			return false
+		}
+		head = bytes.TrimSpace(head[end+2:])
+	}
+	return bytes.HasPrefix(head, []byte("<error>")) || bytes.HasPrefix(head, []byte("<error "))
+}
+
+// maxErrorDocument is the number of bytes read of an error document
+const maxErrorDocument = 64 << 10
+
+// errorDocument is a reply with an error root element. The message is either given in a message element or as text.
+type errorDocument struct {
+	Text    string `xml:",chardata"`
+	Message string `xml:"message"`
+}
+
+func (d errorDocument) message() string {
+	if msg := strings.TrimSpace(d.Message); msg != "" {
+		return msg
+	}
+	return strings.TrimSpace(d.Text)
+}
+
+// isTokenError reports whether the message of an error document is about the token, e.g. "Token ist ungültig"
+func isTokenError(msg string) bool {
+	return strings.Contains(strings.ToLower(msg), "token")
+}
+
+// DocumentError is returned when TUMonline replies with an error document unrelated to the token, e.g. for missing
+// parameters or unknown ids
+type DocumentError struct {
+	Message string
+}
+
+func (e *DocumentError) Error() string {
+	return "TUMonline error: " + e.Message
+}
+
+type bufferedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// StatusError is returned when TUMonline replies with a status other than 200 OK
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return "unexpected response status: " + e.Status
+}
+
+// Is makes 401 Unauthorized and 403 Forbidden match ErrInvalidToken
+func (e *StatusError) Is(target error) bool {
+	return target == ErrInvalidToken && (e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden)
 }
 
 type ContactPerson struct {
-	FirstName   string `json:"first_name"`
-	LastName    string `json:"last_name"`
-	Email       string `json:"email"`
-	Role        string `json:"role"`
-	MainContact bool   `json:"main_contact"`
+	PersonID    string  `json:"person_id"` // stable across courses
+	FirstName   string  `json:"first_name"`
+	LastName    string  `json:"last_name"`
+	Email       string  `json:"email"`
+	Role        string  `json:"role"` // the role texts of the export, e.g. "Leiter/in, Prüfer/in"
+	Roles       []Role  `json:"roles"`
+	MainContact bool    `json:"main_contact"`
+	Address     Address `json:"address"`
+	Telephone   string  `json:"telephone"`
+	VisitHours  string  `json:"visit_hours"`
+	WebLink     string  `json:"web_link"`    // TUMonline's business card of the person
+	PictureURL  string  `json:"picture_url"` // empty if the person has no picture
+}
+
+// Address is the office address of a contact
+type Address struct {
+	Extra      string `json:"extra"` // usually the room, e.g. "Raum 02.07.042"
+	Street     string `json:"street"`
+	Locality   string `json:"locality"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
 }
@@ -0,0 +1,68 @@
+package campusonline
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+// semesterStart and semesterEnd span the winter semester 2021/22 the fixtures are taken from
+var (
+	semesterStart = time.Date(2021, 10, 1, 0, 0, 0, 0, time.Local)
+	semesterEnd   = time.Date(2022, 3, 31, 23, 59, 59, 0, time.Local)
+)
+
+// newTestClient returns a client talking to a fresh fake TUMonline
+func newTestClient(t *testing.T, opts ...Option) (*CampusOnline, *campusonlinetest.Server) {
+	t.Helper()
+	server := campusonlinetest.NewServer()
+	t.Cleanup(server.Close)
+	opts = append([]Option{WithBaseURL(server.BaseURL(), server.BasicBaseURL())}, opts...)
+	c, err := New(campusonlinetest.Token, campusonlinetest.BasicToken, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, server
+}
+
+// fixtureCalendar returns the calendar of the computer science fixture
+func fixtureCalendar(t *testing.T) ICalendar {
+	t.Helper()
+	c, _ := newTestClient(t)
+	cal, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cal
+}
+
+func findCourse(courses []Course, id int) (Course, bool) {
+	for _, course := range courses {
+		if course.CourseID == id {
+			return course, true
+		}
+	}
+	return Course{}, false
+}
+
+func TestGetCourses(t *testing.T) {
+	c, server := newTestClient(t, WithMaxConcurrency(2))
+	server.SetCourse(950000003, []byte("<CDM><course>"))
+	courses, errs := c.GetCourses([]int{950000001, 950000002, 950000003, 1, 950000001})
+	if len(courses) != 2 || courses[950000001].Course.CourseCode != "IN0001" || courses[950000002].Course.CourseID == "" {
+		t.Errorf("expected the valid exports, got %d", len(courses))
+	}
+	if len(errs) != 2 || errs[950000003] == nil {
+		t.Errorf("expected errors for the broken and the unknown course, got %v", errs)
+	}
+	var statusErr *StatusError
+	if !errors.As(errs[1], &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status error 404 for unknown course, got %v", errs[1])
+	}
+	if requests := server.Requests(); len(requests) != 4 {
+		t.Errorf("expected duplicate ids to be exported once, got %d requests", len(requests))
+	}
+}
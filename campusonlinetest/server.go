@@ -0,0 +1,319 @@
+// Package campusonlinetest provides a fake TUMonline for testing code that uses the campusonline package without
+// network access.
+package campusonlinetest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	Token      = "test-token"
+	BasicToken = "test-basic-token"
+)
+
+// Endpoint identifies one of the apis served by Server
+type Endpoint string
+
+const (
+	EndpointXCalOrg      Endpoint = "xcal/organization/courses/xml"
+	EndpointCourse       Endpoint = "cdm/course/xml"
+	EndpointRoom         Endpoint = "rdm/room/schedule/xml"
+	EndpointCourseSearch Endpoint = "veranstaltungenSuche"
+	EndpointOrgTree      Endpoint = "orgBaum"
+)
+
+const (
+	basePath      = "/tumonlinej/ws/webservice_v1.0/"
+	basicBasePath = "/tumonline/wbservicesbasic."
+)
+
+//go:embed fixtures/*.xml
+var fixtures embed.FS
+
+// Fixture returns the content of one of the bundled fixtures, e.g. "xcal_53598.xml". It panics if the fixture
+// doesn't exist.
+func Fixture(name string) []byte {
+	b, err := fixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Server is a fake TUMonline. It serves the bundled fixtures by default, all of which can be replaced.
+// Requests with tokens other than Token and BasicToken are rejected with 401 Unauthorized.
+// Replies carry an ETag, requests with a matching If-None-Match header are answered with 304 Not Modified.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	token      string
+	basicToken string
+	xCal       map[int][]byte
+	courses    map[int][]byte
+	localized  map[int]map[string][]byte
+	rooms      map[int][]byte
+	search     []byte
+	orgTree    []byte
+	failures   map[Endpoint]int
+	latency    time.Duration
+	requests   []string
+}
+
+// NewServer starts a fake TUMonline serving the bundled fixtures. It must be closed by the caller.
+func NewServer() *Server {
+	s := &Server{
+		token:      Token,
+		basicToken: BasicToken,
+		xCal: map[int][]byte{
+			53598: Fixture("xcal_53598.xml"),
+			53599: Fixture("xcal_53599.xml"),
+		},
+		courses: map[int][]byte{
+			950000001: Fixture("cdm_950000001.xml"),
+			950000002: Fixture("cdm_950000002.xml"),
+			950000003: Fixture("cdm_950000003.xml"),
+			950000004: Fixture("cdm_950000004.xml"),
+		},
+		localized: map[int]map[string][]byte{
+			950000001: {"en": Fixture("cdm_950000001_en.xml")},
+		},
+		rooms: map[int][]byte{
+			2300: Fixture("rdm_2300.xml"),
+		},
+		search:   Fixture("search.xml"),
+		orgTree:  Fixture("orgtree.xml"),
+		failures: map[Endpoint]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// BaseURL returns the prefix of the fake webservice_v1.0 api
+func (s *Server) BaseURL() string {
+	return s.URL + basePath
+}
+
+// BasicBaseURL returns the prefix of the fake wbservicesbasic api
+func (s *Server) BasicBaseURL() string {
+	return s.URL + basicBasePath
+}
+
+// SetTokens changes the tokens the server accepts
+func (s *Server) SetTokens(token string, basicToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token, s.basicToken = token, basicToken
+}
+
+// SetXCalOrg sets the xCal document served for the organisation. Events are filtered by the requested dates.
+func (s *Server) SetXCalOrg(orgID int, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.xCal[orgID] = body
+}
+
+// SetCourse sets the CDM document served for the course
+func (s *Server) SetCourse(courseID int, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.courses[courseID] = body
+}
+
+// SetCourseIn sets the CDM document served for the course if it is requested in the language, e.g. "en". Courses
+// requested in other languages are served in the default language like TUMonline does for untranslated courses.
+func (s *Server) SetCourseIn(courseID int, language string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.localized[courseID] == nil {
+		s.localized[courseID] = map[string][]byte{}
+	}
+	s.localized[courseID][language] = body
+}
+
+// SetRoomSchedule sets the RDM document served for the room
+func (s *Server) SetRoomSchedule(roomID int, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rooms[roomID] = body
+}
+
+// SetCourseSearch sets the Rowset document served for every course search
+func (s *Server) SetCourseSearch(body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.search = body
+}
+
+// SetOrgTree sets the document served by the organisation tree api
+func (s *Server) SetOrgTree(body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orgTree = body
+}
+
+// Fail makes the endpoint reply with status until Fail is called with status 0
+func (s *Server) Fail(endpoint Endpoint, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == 0 {
+		delete(s.failures, endpoint)
+		return
+	}
+	s.failures[endpoint] = status
+}
+
+// SetLatency delays every reply by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Requests returns the request uris the server received so far
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.RequestURI())
+	latency := s.latency
+	s.mu.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	endpoint, basic, found := endpointOf(r.URL.Path)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status, failing := s.failures[endpoint]; failing {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if basic && r.URL.Query().Get("pToken") != s.basicToken || !basic && r.URL.Query().Get("token") != s.token {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><error><message>Token ist ungültig</message></error>`)
+		return
+	}
+	q := r.URL.Query()
+	var body []byte
+	switch endpoint {
+	case EndpointXCalOrg:
+		body = filterXCal(s.xCal[atoi(q.Get("orgUnitID"))], q.Get("fromDate"), q.Get("untilDate"))
+	case EndpointCourse:
+		body, found = s.localized[atoi(q.Get("courseID"))][q.Get("language")]
+		if !found {
+			body, found = s.courses[atoi(q.Get("courseID"))]
+		}
+	case EndpointRoom:
+		body, found = s.rooms[atoi(q.Get("roomID"))]
+	case EndpointCourseSearch:
+		body = s.search
+	case EndpointOrgTree:
+		body = s.orgTree
+	}
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=UTF-8")
+	w.Write(body)
+}
+
+func endpointOf(path string) (endpoint Endpoint, basic bool, found bool) {
+	if strings.HasPrefix(path, basicBasePath) {
+		switch Endpoint(strings.TrimPrefix(path, basicBasePath)) {
+		case EndpointCourseSearch:
+			return EndpointCourseSearch, true, true
+		case EndpointOrgTree:
+			return EndpointOrgTree, true, true
+		}
+		return "", false, false
+	}
+	if !strings.HasPrefix(path, basePath) {
+		return "", false, false
+	}
+	switch Endpoint(strings.Trim(strings.TrimPrefix(path, basePath), "/")) {
+	case EndpointXCalOrg:
+		return EndpointXCalOrg, false, true
+	case EndpointCourse:
+		return EndpointCourse, false, true
+	case EndpointRoom:
+		return EndpointRoom, false, true
+	}
+	return "", false, false
+}
+
+const emptyXCal = `<?xml version="1.0" encoding="UTF-8"?>
+<iCalendar xmlns:xCal="urn:ietf:params:xml:ns:xcal"><vcalendar calscale="GREGORIAN" method="PUBLISH" version="2.0"></vcalendar></iCalendar>
+`
+
+// filterXCal drops all vevents from doc that don't start within the days from..until (formatted 20060102)
+func filterXCal(doc []byte, from string, until string) []byte {
+	if doc == nil {
+		return []byte(emptyXCal)
+	}
+	first := bytes.Index(doc, []byte("<vevent>"))
+	last := bytes.LastIndex(doc, []byte("</vevent>"))
+	if first < 0 || last < 0 {
+		return doc
+	}
+	last += len("</vevent>")
+	var res bytes.Buffer
+	res.Write(doc[:first])
+	for _, event := range bytes.SplitAfter(doc[first:last], []byte("</vevent>")) {
+		start := between(event, "<dtstart>", "</dtstart>")
+		if len(start) < 8 {
+			continue
+		}
+		day := string(start[:8])
+		if day >= from && day <= until {
+			res.Write(event)
+		}
+	}
+	res.Write(doc[last:])
+	return res.Bytes()
+}
+
+func between(b []byte, open string, close string) []byte {
+	start := bytes.Index(b, []byte(open))
+	if start < 0 {
+		return nil
+	}
+	start += len(open)
+	end := bytes.Index(b[start:], []byte(close))
+	if end < 0 {
+		return nil
+	}
+	return b[start : start+end]
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
@@ -0,0 +1,206 @@
+// Package cassette records responses of TUMonline to disk and replays them deterministically, so tests and bug
+// reports can reproduce real responses without access to TUMonline or its tokens.
+//
+// Record by injecting a Recorder into the client and saving it afterwards:
+//
+//	rec := cassette.NewRecorder(nil, token, basicToken)
+//	co, _ := campusonline.New(token, basicToken, campusonline.WithTransport(rec))
+//	// use co
+//	rec.Save("bug.json")
+//
+// and replay with a Replayer:
+//
+//	rep, _ := cassette.Load("bug.json")
+//	co, _ := campusonline.New("", "", campusonline.WithTransport(rep))
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces tokens in recorded urls and bodies
+const Redacted = "REDACTED"
+
+// tokenParams are the query parameters TUMonline expects tokens in
+var tokenParams = []string{"token", "pToken"}
+
+// Interaction is a single recorded request and its response
+type Interaction struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// Cassette is a list of recorded interactions as stored on disk
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is a http.RoundTripper that passes requests on to the wrapped transport and records the responses
+type Recorder struct {
+	transport http.RoundTripper
+	secrets   []string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder sending requests with transport, or http.DefaultTransport if it is nil.
+// Values of token query parameters and all secrets are scrubbed from the recording. The tokens of each request are
+// scrubbed from its response too, so secrets only need to be passed for values not sent as token parameters.
+func NewRecorder(transport http.RoundTripper, secrets ...string) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{transport: transport, secrets: secrets}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	secrets := append(requestTokens(req.URL), r.secrets...)
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	for _, values := range header {
+		for i := range values {
+			values[i] = scrub(values[i], secrets)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Method: req.Method,
+		URL:    scrubURL(req.URL, secrets),
+		Status: resp.StatusCode,
+		Header: header,
+		Body:   scrub(string(body), secrets),
+	})
+	return resp, nil
+}
+
+// Cassette returns the interactions recorded so far
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// Save writes the interactions recorded so far to path. The file is only readable by the owner, as responses contain
+// personal data.
+func (r *Recorder) Save(path string) error {
+	b, err := json.MarshalIndent(r.Cassette(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// Replayer is a http.RoundTripper answering requests from a cassette without network access.
+// Requests are matched by method and url (ignoring tokens). Identical requests are answered in recorded order,
+// once all recordings are used up the last one is repeated.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+	served       map[string]int
+}
+
+// NewReplayer returns a Replayer serving the interactions of c
+func NewReplayer(c Cassette) *Replayer {
+	r := &Replayer{interactions: map[string][]Interaction{}, served: map[string]int{}}
+	for _, i := range c.Interactions {
+		key := i.Method + " " + i.URL
+		r.interactions[key] = append(r.interactions[key], i)
+	}
+	return r
+}
+
+// Load returns a Replayer serving the cassette stored at path
+func Load(path string) (*Replayer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("cassette %s: %v", path, err)
+	}
+	return NewReplayer(c), nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.Method + " " + scrubURL(req.URL, nil)
+	r.mu.Lock()
+	recorded := r.interactions[key]
+	n := r.served[key]
+	r.served[key]++
+	r.mu.Unlock()
+	if len(recorded) == 0 {
+		return nil, fmt.Errorf("cassette: no recorded response for %s", key)
+	}
+	if n >= len(recorded) {
+		n = len(recorded) - 1
+	}
+	i := recorded[n]
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+		StatusCode:    i.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.Header.Clone(),
+		Body:          ioutil.NopCloser(strings.NewReader(i.Body)),
+		ContentLength: int64(len(i.Body)),
+		Request:       req,
+	}, nil
+}
+
+// scrubURL returns u with the values of token parameters and all secrets replaced by Redacted. Empty tokens are
+// replaced too, so requests of clients without tokens match the recorded ones.
+func scrubURL(u *url.URL, secrets []string) string {
+	scrubbed := *u
+	q := scrubbed.Query()
+	for _, param := range tokenParams {
+		if _, found := q[param]; found {
+			q.Set(param, Redacted)
+		}
+	}
+	scrubbed.RawQuery = q.Encode()
+	return scrub(scrubbed.String(), secrets)
+}
+
+// requestTokens returns the values of the token parameters of u
+func requestTokens(u *url.URL) []string {
+	var tokens []string
+	q := u.Query()
+	for _, param := range tokenParams {
+		tokens = append(tokens, q[param]...)
+	}
+	return tokens
+}
+
+func scrub(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+	return s
+}
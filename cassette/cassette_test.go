@@ -0,0 +1,140 @@
+package cassette_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+	"github.com/RBG-TUM/CAMPUSOnline/cassette"
+)
+
+var (
+	from  = time.Date(2021, 10, 1, 0, 0, 0, 0, time.Local)
+	until = time.Date(2022, 3, 31, 0, 0, 0, 0, time.Local)
+)
+
+func TestRecordReplay(t *testing.T) {
+	server := campusonlinetest.NewServer()
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec := cassette.NewRecorder(nil, campusonlinetest.Token, campusonlinetest.BasicToken)
+	co, err := campusonline.New(campusonlinetest.Token, campusonlinetest.BasicToken,
+		campusonline.WithBaseURL(server.BaseURL(), server.BasicBaseURL()), campusonline.WithTransport(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := co.GetXCalOrg(from, until, campusonline.CsOrgId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := co.SearchCourses("Informatik", campusonline.Semester{Year: 2021, Term: campusonline.Winter}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected cassette only readable by the owner, got %v (%v)", info.Mode(), err)
+	}
+	saved, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{campusonlinetest.Token, campusonlinetest.BasicToken} {
+		if strings.Contains(string(saved), token) {
+			t.Errorf("cassette contains token %s", token)
+		}
+	}
+
+	rep, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tokens are ignored during replay, so a cassette can be replayed without knowing them
+	co, err = campusonline.New("", "",
+		campusonline.WithBaseURL(server.BaseURL(), server.BasicBaseURL()), campusonline.WithTransport(rep))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		replayed, err := co.GetXCalOrg(from, until, campusonline.CsOrgId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(replayed.Vcalendar.Events) != len(recorded.Vcalendar.Events) {
+			t.Errorf("expected %d replayed events, got %d", len(recorded.Vcalendar.Events), len(replayed.Vcalendar.Events))
+		}
+	}
+	if _, err := co.GetXCalOrg(from, until, campusonline.CeOrgId); err == nil {
+		t.Error("expected error for request missing from the cassette")
+	}
+}
+
+func TestRecordScrubsBodies(t *testing.T) {
+	server := campusonlinetest.NewServer()
+	defer server.Close()
+	server.SetCourse(1, []byte("<CDM><course><courseDescription>token "+campusonlinetest.Token+"</courseDescription></course></CDM>"))
+
+	// the token is scrubbed without passing it as a secret, as it was sent with the request
+	rec := cassette.NewRecorder(nil)
+	co, err := campusonline.New(campusonlinetest.Token, campusonlinetest.BasicToken,
+		campusonline.WithBaseURL(server.BaseURL(), server.BasicBaseURL()), campusonline.WithTransport(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := co.LoadCourseContacts([]campusonline.Course{{CourseID: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	interactions := rec.Cassette().Interactions
+	if len(interactions) != 1 {
+		t.Fatalf("expected one interaction, got %d", len(interactions))
+	}
+	i := interactions[0]
+	if strings.Contains(i.Body, campusonlinetest.Token) || !strings.Contains(i.Body, "token "+cassette.Redacted) {
+		t.Errorf("body not scrubbed: %s", i.Body)
+	}
+	if !strings.Contains(i.URL, "token="+cassette.Redacted) || i.Status != 200 {
+		t.Errorf("unexpected interaction %+v", i)
+	}
+}
+
+// roundTripFunc turns a function into a http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRecordScrubsHeaders(t *testing.T) {
+	redirect := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusFound,
+			Header:     http.Header{"Location": {req.URL.String()}, "Content-Location": {"/x?pToken=" + campusonlinetest.BasicToken}},
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	})
+	rec := cassette.NewRecorder(redirect)
+	req, err := http.NewRequest(http.MethodGet, "https://campus.tum.de/x?token="+campusonlinetest.Token+"&pToken="+campusonlinetest.BasicToken, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range rec.Cassette().Interactions[0].Header {
+		for _, value := range values {
+			if strings.Contains(value, campusonlinetest.Token) || strings.Contains(value, campusonlinetest.BasicToken) {
+				t.Errorf("header %s not scrubbed: %s", name, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Command campusonline-server exposes TUMonline course data as a JSON api, so services that aren't written in Go
+// get the same cleaned up data without knowing the TUMonline tokens.
+//
+// Endpoints:
+//
+//	GET /orgs/{id}/courses?semester=2024W&filter=true&contacts=true
+//	GET /courses/{id}
+//	GET /rooms/{id}/schedule?from=2024-10-14&until=2024-10-18&type=course,exam
+//	GET /search?q=Informatik&semester=2024W&org=TUS1200&type=VO,UE&offset=0&limit=20
+//	GET /subscriptions/{courses, rooms or orgs}/{id}.ics?semester=2024W&filter=true
+//	GET /feeds/courses/{id}.ics, /feeds/rooms/{id}.ics, /feeds/orgs/{id}.ics
+//
+// Organisations may be given by id or code. Date ranges default to the current semester. Searches return a page of
+// rows together with the total number of results and the offset of the page.
+// Every request except the iCalendar subscriptions (see package feed) needs one of the api keys listed in
+// CAMPUSONLINE_SERVER_API_KEYS (comma separated), passed as "X-API-Key" header or bearer token. Calendar apps can't
+// send api keys, so /subscriptions returns the feed url with a token signed with CAMPUSONLINE_SERVER_FEED_SECRET
+// that only grants access to this feed. Course feeds only find courses in the calendars of the organisations given
+// with -feed-orgs, computer science and computer engineering by default. The TUMonline tokens are read from
+// CAMPUSONLINE_TOKEN and CAMPUSONLINE_BASIC_TOKEN.
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	ttl := flag.Duration("cache-ttl", time.Minute*15, "how long responses are cached")
+	orgs := flag.String("feed-orgs", fmt.Sprintf("%d,%d", campusonline.CsOrgId, campusonline.CeOrgId),
+		"comma separated ids of the organisations whose calendars are searched for course feeds")
+	flag.Parse()
+	feedOrgs, err := parseOrgIDs(*orgs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var keys []string
+	for _, key := range strings.Split(os.Getenv("CAMPUSONLINE_SERVER_API_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		log.Fatal("CAMPUSONLINE_SERVER_API_KEYS is not set")
+	}
+	secret := []byte(os.Getenv("CAMPUSONLINE_SERVER_FEED_SECRET"))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatal(err)
+		}
+		log.Print("CAMPUSONLINE_SERVER_FEED_SECRET is not set, feed urls are only valid until the server restarts")
+	}
+	co, err := campusonline.New("", "",
+		campusonline.WithTokenProvider(campusonline.EnvToken("CAMPUSONLINE_TOKEN")),
+		campusonline.WithBasicTokenProvider(campusonline.EnvToken("CAMPUSONLINE_BASIC_TOKEN")),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s, err := newServer(co, keys, secret, *ttl, feedOrgs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, s))
+}
+
+// parseOrgIDs parses a comma separated list of organisation ids
+func parseOrgIDs(s string) ([]int, error) {
+	var ids []int
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid organisation id %q in -feed-orgs", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
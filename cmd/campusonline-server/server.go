@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+	"github.com/RBG-TUM/CAMPUSOnline/feed"
+	"github.com/dgraph-io/ristretto"
+)
+
+// server serves the json api. Successful responses are cached for ttl.
+type server struct {
+	co    *campusonline.CampusOnline
+	keys  []string
+	cache *ristretto.Cache
+	ttl   time.Duration
+	feeds *feed.Handler
+}
+
+// newServer returns a server for co that signs feed urls with feedSecret. Course feeds search the calendars of
+// feedOrgs, or those of the feed package's default organisations if there are none.
+func newServer(co *campusonline.CampusOnline, keys []string, feedSecret []byte, ttl time.Duration, feedOrgs []int) (*server, error) {
+	cache, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 1e5,
+		MaxCost:     1 << 28, // 256MB of responses
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil, err
+	}
+	feedOpts := []feed.Option{feed.WithTTL(ttl), feed.WithSecret(feedSecret)}
+	if len(feedOrgs) != 0 {
+		feedOpts = append(feedOpts, feed.WithOrgs(feedOrgs...))
+	}
+	feeds := feed.NewHandler(co, feedOpts...)
+	return &server{co: co, keys: keys, cache: cache, ttl: ttl, feeds: feeds}, nil
+}
+
+// httpError is an error with the status it should be reported with
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{status: http.StatusBadRequest, msg: fmt.Sprintf(format, args...)}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/feeds/") {
+		http.StripPrefix("/feeds", s.feeds).ServeHTTP(w, r) // calendar apps can't send api keys, feeds are signed instead
+		return
+	}
+	if !s.authorized(r) {
+		writeError(w, &httpError{status: http.StatusUnauthorized, msg: "missing or invalid api key"})
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, &httpError{status: http.StatusMethodNotAllowed, msg: "only GET is supported"})
+		return
+	}
+	key := r.URL.Path + "?" + r.URL.Query().Encode()
+	if cached, found := s.cache.Get(key); found {
+		writeJSON(w, cached.([]byte))
+		return
+	}
+	res, err := s.route(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	body, err := json.Marshal(res)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.cache.SetWithTTL(key, body, int64(len(body)), s.ttl)
+	writeJSON(w, body)
+}
+
+func (s *server) authorized(r *http.Request) bool {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if key == "" {
+		return false
+	}
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// route dispatches the request by its path segments
+func (s *server) route(r *http.Request) (interface{}, error) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(segments) == 3 && segments[0] == "orgs" && segments[2] == "courses":
+		return s.orgCourses(r, segments[1])
+	case len(segments) == 2 && segments[0] == "courses":
+		return s.course(segments[1])
+	case len(segments) == 3 && segments[0] == "rooms" && segments[2] == "schedule":
+		return s.roomSchedule(r, segments[1])
+	case len(segments) == 1 && segments[0] == "search":
+		return s.search(r)
+	case len(segments) == 3 && segments[0] == "subscriptions" && strings.HasSuffix(segments[2], ".ics"):
+		return s.subscription(r, segments[1]+"/"+segments[2])
+	}
+	return nil, &httpError{status: http.StatusNotFound, msg: "not found"}
+}
+
+func (s *server) orgCourses(r *http.Request, org string) (interface{}, error) {
+	from, until, err := dateRange(r)
+	if err != nil {
+		return nil, err
+	}
+	orgID, err := s.resolveOrg(org)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := s.co.GetXCalOrg(from, until, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if r.URL.Query().Get("filter") == "true" {
+		cal.Filter()
+	}
+	cal.Sort()
+	courses := cal.GroupByCourse()
+	sort.Slice(courses, func(i, j int) bool { return courses[i].CourseID < courses[j].CourseID })
+	if r.URL.Query().Get("contacts") == "true" {
+		if courses, err = s.co.LoadCourseContacts(courses); err != nil {
+			return nil, err
+		}
+	}
+	if courses == nil {
+		courses = []campusonline.Course{}
+	}
+	return courses, nil
+}
+
+// subscription returns the signed url calendar apps subscribe to for the feed, e.g. "rooms/2300.ics"
+func (s *server) subscription(r *http.Request, path string) (interface{}, error) {
+	return map[string]string{"url": "/feeds" + s.feeds.URL(path, r.URL.Query())}, nil
+}
+
+func (s *server) course(id string) (interface{}, error) {
+	courseID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, badRequest("invalid course id %q", id)
+	}
+	courses, err := s.co.LoadCourseContacts([]campusonline.Course{{CourseID: courseID, Events: []campusonline.Event{}}})
+	if err != nil {
+		return nil, err
+	}
+	return courses[0], nil
+}
+
+func (s *server) roomSchedule(r *http.Request, id string) (interface{}, error) {
+	roomID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, badRequest("invalid room id %q", id)
+	}
+	from, until, err := dateRange(r)
+	if err != nil {
+		return nil, err
+	}
+	rdm, err := s.co.GetRoomSchedule(roomID, from, until)
+	if err != nil {
+		return nil, err
+	}
+	var filter campusonline.BookingFilter
+	if types := r.URL.Query().Get("type"); types != "" {
+		for _, name := range strings.Split(types, ",") {
+			t := campusonline.ParseBookingType(name)
+			if t == campusonline.BookingUnknown {
+				return nil, badRequest("invalid booking type %q", name)
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+	events := []campusonline.Event{}
+	for _, booking := range campusonline.FilterBookings(rdm.Bookings(), filter) {
+		events = append(events, campusonline.Event{
+			Title:    booking.Title,
+			RoomID:   roomID,
+			Start:    booking.Start,
+			End:      booking.End,
+			RoomName: booking.RoomName,
+			Comment:  booking.Status,
+			EventID:  booking.EventID,
+		})
+	}
+	return events, nil
+}
+
+// resolveOrg returns the id of an organisation given by id or code
+func (s *server) resolveOrg(ref string) (int, error) {
+	if orgID, err := strconv.Atoi(ref); err == nil {
+		return orgID, nil
+	}
+	o, err := s.co.LookupOrganisation(ref)
+	if err != nil {
+		return 0, &httpError{status: http.StatusNotFound, msg: err.Error()}
+	}
+	return o.ID, nil
+}
+
+func (s *server) search(r *http.Request) (interface{}, error) {
+	params := r.URL.Query()
+	query := params.Get("q")
+	if query == "" {
+		return nil, badRequest("q is required")
+	}
+	semester, err := semesterParam(r)
+	if err != nil {
+		return nil, err
+	}
+	opts := campusonline.SearchOptions{Semester: semester, IncludeSubOrgs: true}
+	if org := params.Get("org"); org != "" {
+		if opts.OrgID, err = s.resolveOrg(org); err != nil {
+			return nil, err
+		}
+	}
+	if types := params.Get("type"); types != "" {
+		opts.Types = strings.Split(types, ",")
+	}
+	for name, value := range map[string]*int{"offset": &opts.Offset, "limit": &opts.Limit} {
+		if param := params.Get(name); param != "" {
+			if *value, err = strconv.Atoi(param); err != nil || *value < 0 {
+				return nil, badRequest("invalid %s %q", name, param)
+			}
+		}
+	}
+	res, err := s.co.Search(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	if res.Rows == nil {
+		res.Rows = []campusonline.RowsetRow{}
+	}
+	return res, nil
+}
+
+func semesterParam(r *http.Request) (campusonline.Semester, error) {
+	param := r.URL.Query().Get("semester")
+	if param == "" {
+		return campusonline.CurrentSemester(), nil
+	}
+	semester, err := campusonline.ParseSemester(param)
+	if err != nil {
+		return campusonline.Semester{}, badRequest("%v", err)
+	}
+	return semester, nil
+}
+
+// dateRange reads the from and until parameters, falling back to the bounds of the semester parameter
+func dateRange(r *http.Request) (time.Time, time.Time, error) {
+	semester, err := semesterParam(r)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	from, until := semester.Start(), semester.End()
+	if param := r.URL.Query().Get("from"); param != "" {
+		if from, err = time.ParseInLocation("2006-01-02", param, time.Local); err != nil {
+			return time.Time{}, time.Time{}, badRequest("invalid from: %v", err)
+		}
+	}
+	if param := r.URL.Query().Get("until"); param != "" {
+		if until, err = time.ParseInLocation("2006-01-02", param, time.Local); err != nil {
+			return time.Time{}, time.Time{}, badRequest("invalid until: %v", err)
+		}
+	}
+	if until.Before(from) {
+		return time.Time{}, time.Time{}, badRequest("until is before from")
+	}
+	return from, until, nil
+}
+
+func writeJSON(w http.ResponseWriter, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway // errors talking to TUMonline
+	var httpErr *httpError
+	var statusErr *campusonline.StatusError
+	switch {
+	case errors.As(err, &httpErr):
+		status = httpErr.status
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound:
+		status = http.StatusNotFound
+	case errors.Is(err, campusonline.ErrInvalidToken):
+		log.Printf("TUMonline rejected the token: %v", err)
+	}
+	var body bytes.Buffer
+	json.NewEncoder(&body).Encode(map[string]string{"error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body.Bytes())
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+const testKey = "test-api-key"
+
+func newTestServer(t *testing.T, feedOrgs ...int) (*httptest.Server, *campusonlinetest.Server) {
+	t.Helper()
+	fake := campusonlinetest.NewServer()
+	t.Cleanup(fake.Close)
+	co, err := campusonline.New(campusonlinetest.Token, campusonlinetest.BasicToken,
+		campusonline.WithBaseURL(fake.BaseURL(), fake.BasicBaseURL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newServer(co, []string{testKey}, []byte("feed secret"), time.Minute, feedOrgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return ts, fake
+}
+
+// get requests path with the test api key and decodes the json response into v
+func get(t *testing.T, ts *httptest.Server, path string, v interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", testKey)
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode
+}
+
+func TestOrgCourses(t *testing.T) {
+	ts, fake := newTestServer(t)
+	var courses []campusonline.Course
+	if status := get(t, ts, "/orgs/TUS1200/courses?semester=2021W&filter=true&contacts=true", &courses); status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if len(courses) != 4 || courses[0].CourseID != 950000001 {
+		t.Fatalf("unexpected courses %+v", courses)
+	}
+	if len(courses[0].Contacts) == 0 {
+		t.Errorf("expected contacts to be loaded")
+	}
+	requests := len(fake.Requests())
+	get(t, ts, "/orgs/TUS1200/courses?semester=2021W&filter=true&contacts=true", &courses)
+	if len(fake.Requests()) != requests {
+		t.Errorf("expected the second response to be served from the cache")
+	}
+}
+
+func TestCourse(t *testing.T) {
+	ts, fake := newTestServer(t)
+	var course campusonline.Course
+	if status := get(t, ts, "/courses/950000001", &course); status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if course.CourseID != 950000001 || course.Title == "" || len(course.Contacts) == 0 {
+		t.Errorf("unexpected course %+v", course)
+	}
+	if requests := fake.Requests(); len(requests) != 1 {
+		t.Errorf("expected the course to be exported once, got %v", requests)
+	}
+	var res map[string]string
+	if status := get(t, ts, "/courses/1", &res); status != http.StatusNotFound || res["error"] == "" {
+		t.Errorf("expected 404 with error for unknown course, got %d %v", status, res)
+	}
+}
+
+func TestRoomScheduleEndpoint(t *testing.T) {
+	ts, _ := newTestServer(t)
+	var events []campusonline.Event
+	if status := get(t, ts, "/rooms/2300/schedule?semester=2021W", &events); status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if len(events) != 6 || events[0].RoomID != 2300 || events[0].Start.IsZero() {
+		t.Errorf("unexpected events %+v", events)
+	}
+	if status := get(t, ts, "/rooms/2300/schedule?semester=2021W&type=exam,blocked", &events); status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if len(events) != 2 || events[0].Title != "Klausur Analysis" {
+		t.Errorf("expected the exam and the blocked booking, got %+v", events)
+	}
+	var res map[string]string
+	if status := get(t, ts, "/rooms/2300/schedule?type=party", &res); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown type, got %d", status)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ts, _ := newTestServer(t)
+	var page campusonline.SearchResult
+	if status := get(t, ts, "/search?q=Informatik&semester=2021W", &page); status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if len(page.Rows) != 3 || page.Total != 3 {
+		t.Errorf("expected 3 results, got %+v", page)
+	}
+	page = campusonline.SearchResult{}
+	if status := get(t, ts, "/search?q=Informatik&semester=2021W&offset=1&limit=1", &page); status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if len(page.Rows) != 1 || page.Total != 3 || page.Offset != 1 || !page.HasMore() {
+		t.Errorf("expected the second of 3 results, got %+v", page)
+	}
+	page = campusonline.SearchResult{}
+	if status := get(t, ts, "/search?q=Informatik&semester=2021W&org=TUS1200&type=VO&limit=1", &page); status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if len(page.Rows) != 1 || page.Rows[0].StpSpNr != "950000001" {
+		t.Errorf("expected the first lecture, got %+v", page)
+	}
+	var empty map[string]json.RawMessage
+	if status := get(t, ts, "/search?q=Informatik&semester=2021W&offset=10", &empty); status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if string(empty["rows"]) != "[]" || string(empty["total"]) != "3" {
+		t.Errorf("expected empty rows and the total, got %s %s", empty["rows"], empty["total"])
+	}
+	var res map[string]string
+	if status := get(t, ts, "/search", &res); status != http.StatusBadRequest {
+		t.Errorf("expected 400 without query, got %d", status)
+	}
+	if status := get(t, ts, "/search?q=Informatik&limit=-1", &res); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid limit, got %d", status)
+	}
+}
+
+func TestUpstreamFailure(t *testing.T) {
+	ts, fake := newTestServer(t)
+	fake.Fail(campusonlinetest.EndpointXCalOrg, http.StatusInternalServerError)
+	var res map[string]string
+	if status := get(t, ts, "/orgs/53598/courses?semester=2021W", &res); status != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", status)
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	ts, _ := newTestServer(t)
+	for _, header := range []string{"", "wrong"} {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/search?q=x", nil)
+		if header != "" {
+			req.Header.Set("Authorization", "Bearer "+header)
+		}
+		res, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected 401 for key %q, got %d", header, res.StatusCode)
+		}
+	}
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/search?q=x&semester=2021W", nil)
+	req.Header.Set("Authorization", "Bearer "+testKey)
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected bearer token to be accepted, got %d", res.StatusCode)
+	}
+}
+
+func TestFeedsWithoutAPIKey(t *testing.T) {
+	ts, _ := newTestServer(t)
+	res, err := ts.Client().Get(ts.URL + "/feeds/rooms/2300.ics?semester=2021W")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("expected unsigned feed to be rejected, got %d", res.StatusCode)
+	}
+
+	var subscription map[string]string
+	if status := get(t, ts, "/subscriptions/rooms/2300.ics?semester=2021W", &subscription); status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	res, err = ts.Client().Get(ts.URL + subscription["url"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/calendar; charset=utf-8" {
+		t.Errorf("expected calendar for %s, got %d %s", subscription["url"], res.StatusCode, res.Header.Get("Content-Type"))
+	}
+}
+
+func TestFeedOrgs(t *testing.T) {
+	ts, _ := newTestServer(t, campusonline.CeOrgId)
+	for course, expected := range map[int]int{950000010: http.StatusOK, 950000002: http.StatusNotFound} {
+		var subscription map[string]string
+		path := fmt.Sprintf("/subscriptions/courses/%d.ics?semester=2021W", course)
+		if status := get(t, ts, path, &subscription); status != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", status)
+		}
+		res, err := ts.Client().Get(ts.URL + subscription["url"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != expected {
+			t.Errorf("course %d: expected status %d, got %d", course, expected, res.StatusCode)
+		}
+	}
+	if ids, err := parseOrgIDs(" 53598, 53599,"); err != nil || len(ids) != 2 || ids[1] != campusonline.CeOrgId {
+		t.Errorf("unexpected organisations %v (%v)", ids, err)
+	}
+	if _, err := parseOrgIDs("TUS1200"); err == nil {
+		t.Error("expected error for organisation code")
+	}
+}
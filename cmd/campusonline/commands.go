@@ -0,0 +1,366 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+)
+
+const timeLayout = "2006-01-02 15:04"
+
+func orgCalendar(e *env, args []string) (result, error) {
+	fs := e.flagSet("org-calendar", true)
+	org := fs.String("org", "", "organisation id or code, e.g. 53598 or TUS1200 (required)")
+	tree := fs.Bool("tree", false, "include all sub-organisations")
+	filter := fs.Bool("filter", false, "only keep events in recorded lecture halls")
+	courses := fs.Bool("courses", false, "group events by course")
+	if err := fs.Parse(args); err != nil {
+		return result{}, err
+	}
+	if *org == "" {
+		return result{}, fmt.Errorf("-org is required")
+	}
+	from, until, err := e.flags.dateRange()
+	if err != nil {
+		return result{}, err
+	}
+	c, err := e.client()
+	if err != nil {
+		return result{}, err
+	}
+	orgID, err := resolveOrg(c, *org)
+	if err != nil {
+		return result{}, err
+	}
+	var cal campusonline.ICalendar
+	if *tree {
+		cal, err = c.GetXCalOrgTree(from, until, orgID)
+	} else {
+		cal, err = c.GetXCalOrg(from, until, orgID)
+	}
+	if err != nil {
+		return result{}, err
+	}
+	if *filter {
+		cal.Filter()
+	}
+	cal.Sort()
+	name := fmt.Sprintf("TUMonline %s", *org)
+	if *courses {
+		grouped := cal.GroupByCourse()
+		sort.Slice(grouped, func(i, j int) bool { return grouped[i].CourseID < grouped[j].CourseID })
+		return coursesResult(grouped, name), nil
+	}
+	res := result{
+		header:   []string{"start", "end", "title", "location", "status", "uid"},
+		data:     append(campusonline.Events{}, cal.Vcalendar.Events...),
+		calendar: true,
+		events:   cal.ICSEvents(),
+		name:     name,
+	}
+	for _, event := range res.events {
+		res.rows = append(res.rows, []string{
+			event.Start.Format(timeLayout), event.End.Format(timeLayout), event.Summary, event.Location, event.Status, event.UID,
+		})
+	}
+	return res, nil
+}
+
+func coursesResult(courses []campusonline.Course, name string) result {
+	res := result{
+		header:   []string{"course_id", "slug", "title", "events", "first", "last"},
+		data:     append([]campusonline.Course{}, courses...),
+		calendar: true,
+		events:   campusonline.CourseICSEvents(courses),
+		name:     name,
+	}
+	for _, course := range courses {
+		first, last := "", ""
+		if len(course.Events) > 0 {
+			first = course.Events[0].Start.Format(timeLayout)
+			last = course.Events[len(course.Events)-1].Start.Format(timeLayout)
+		}
+		res.rows = append(res.rows, []string{
+			strconv.Itoa(course.CourseID), course.Slug, course.Title, strconv.Itoa(len(course.Events)), first, last,
+		})
+	}
+	return res
+}
+
+// resolveOrg returns the id of an organisation given by id or code
+func resolveOrg(c *campusonline.CampusOnline, ref string) (int, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return id, nil
+	}
+	org, err := c.LookupOrganisation(ref)
+	if err != nil {
+		return 0, err
+	}
+	return org.ID, nil
+}
+
+// roomBooking is the json representation of a room schedule entry
+type roomBooking struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Type   string    `json:"type"`
+	Title  string    `json:"title"`
+	Status string    `json:"status"`
+}
+
+func roomSchedule(e *env, args []string) (result, error) {
+	fs := e.flagSet("room-schedule", true)
+	room := fs.Int("room", 0, "TUMonline room id (required)")
+	types := fs.String("type", "", "comma separated booking types to keep: course, exam or blocked")
+	if err := fs.Parse(args); err != nil {
+		return result{}, err
+	}
+	if *room == 0 {
+		return result{}, fmt.Errorf("-room is required")
+	}
+	var filter campusonline.BookingFilter
+	if *types != "" {
+		for _, name := range strings.Split(*types, ",") {
+			t := campusonline.ParseBookingType(name)
+			if t == campusonline.BookingUnknown {
+				return result{}, fmt.Errorf("invalid booking type %q", name)
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+	from, until, err := e.flags.dateRange()
+	if err != nil {
+		return result{}, err
+	}
+	c, err := e.client()
+	if err != nil {
+		return result{}, err
+	}
+	rdm, err := c.GetRoomSchedule(*room, from, until)
+	if err != nil {
+		return result{}, err
+	}
+	bookings := []roomBooking{}
+	res := result{
+		header:   []string{"start", "end", "type", "title", "status"},
+		calendar: true,
+		name:     fmt.Sprintf("TUMonline room %d", *room),
+	}
+	rdm = rdm.Filter(filter)
+	for _, booking := range rdm.Bookings() {
+		b := roomBooking{Start: booking.Start, End: booking.End, Type: booking.TypeCode, Title: booking.Title, Status: booking.Status}
+		bookings = append(bookings, b)
+		res.rows = append(res.rows, []string{b.Start.Format(timeLayout), b.End.Format(timeLayout), b.Type, b.Title, b.Status})
+	}
+	res.events = rdm.ICSEvents()
+	res.data = bookings
+	return res, nil
+}
+
+// courseDetails is the json representation of a course export
+type courseDetails struct {
+	CourseID     int                          `json:"course_id"`
+	Name         string                       `json:"name"`
+	Code         string                       `json:"code"`
+	Type         string                       `json:"type"`
+	TeachingTerm string                       `json:"teaching_term"`
+	HoursPerWeek string                       `json:"hours_per_week"`
+	Description  string                       `json:"description"`
+	Contacts     []campusonline.ContactPerson `json:"contacts"`
+}
+
+func course(e *env, args []string) (result, error) {
+	fs := e.flagSet("course", false)
+	id := fs.Int("id", 0, "TUMonline course id (required)")
+	fs.StringVar(&e.flags.lang, "lang", "de", "language of the course texts, de or en")
+	if err := fs.Parse(args); err != nil {
+		return result{}, err
+	}
+	if *id == 0 {
+		return result{}, fmt.Errorf("-id is required")
+	}
+	c, err := e.client()
+	if err != nil {
+		return result{}, err
+	}
+	cdm, err := c.ExportCourseIn(*id, campusonline.Language(e.flags.lang))
+	if err != nil {
+		return result{}, err
+	}
+	details := courseDetails{
+		CourseID:     *id,
+		Name:         strings.TrimSpace(cdm.Course.CourseName.Text),
+		Code:         strings.TrimSpace(cdm.Course.CourseCode),
+		Type:         cdm.Course.TypeName,
+		TeachingTerm: strings.TrimSpace(cdm.Course.TeachingTerm),
+		HoursPerWeek: cdm.Course.Credits.HoursPerWeek,
+		Description:  strings.TrimSpace(cdm.Course.CourseDescription),
+		Contacts:     c.ContactsOf(cdm),
+	}
+	var contactNames []string
+	for _, contact := range details.Contacts {
+		contactNames = append(contactNames, contact.FirstName+" "+contact.LastName)
+	}
+	return result{
+		header: []string{"field", "value"},
+		rows: [][]string{
+			{"course_id", strconv.Itoa(details.CourseID)},
+			{"name", details.Name},
+			{"code", details.Code},
+			{"type", details.Type},
+			{"teaching_term", details.TeachingTerm},
+			{"hours_per_week", details.HoursPerWeek},
+			{"contacts", strings.Join(contactNames, ", ")},
+		},
+		data: details,
+	}, nil
+}
+
+func search(e *env, args []string) (result, error) {
+	fs := e.flagSet("search", false)
+	query := fs.String("q", "", "search term (required)")
+	fs.StringVar(&e.flags.semester, "semester", "", "semester, e.g. 2024W, defaults to the current semester")
+	org := fs.String("org", "", "only keep courses of the organisation and its sub-organisations, by id or code")
+	types := fs.String("type", "", "comma separated course types to keep, e.g. VO,UE")
+	offset := fs.Int("offset", 0, "number of results to skip")
+	limit := fs.Int("limit", 0, "maximum number of results, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		return result{}, err
+	}
+	if *query == "" {
+		return result{}, fmt.Errorf("-q is required")
+	}
+	semester, err := e.flags.semesterValue()
+	if err != nil {
+		return result{}, err
+	}
+	c, err := e.client()
+	if err != nil {
+		return result{}, err
+	}
+	opts := campusonline.SearchOptions{Semester: semester, Offset: *offset, Limit: *limit}
+	if *org != "" {
+		if opts.OrgID, err = resolveOrg(c, *org); err != nil {
+			return result{}, err
+		}
+		opts.IncludeSubOrgs = true
+	}
+	if *types != "" {
+		opts.Types = strings.Split(*types, ",")
+	}
+	found, err := c.Search(*query, opts)
+	if err != nil {
+		return result{}, err
+	}
+	res := result{
+		header: []string{"course_id", "title", "type", "sws", "semester", "organisation", "lecturers"},
+		data:   found.Rows,
+	}
+	for _, row := range found.Rows {
+		res.rows = append(res.rows, []string{
+			row.StpSpNr, row.StpSpTitel, row.StpLvArtKurz, row.StpSpSst, row.SemesterID, row.OrgNameBetreut,
+			strings.TrimSpace(row.VortragendeMitwirkende.Text),
+		})
+	}
+	return res, nil
+}
+
+func contacts(e *env, args []string) (result, error) {
+	fs := e.flagSet("contacts", false)
+	id := fs.Int("course", 0, "TUMonline course id (required)")
+	fs.StringVar(&e.flags.lang, "lang", "de", "language of the roles, de or en")
+	if err := fs.Parse(args); err != nil {
+		return result{}, err
+	}
+	if *id == 0 {
+		return result{}, fmt.Errorf("-course is required")
+	}
+	c, err := e.client()
+	if err != nil {
+		return result{}, err
+	}
+	courses, err := c.LoadCourseContacts([]campusonline.Course{{CourseID: *id}})
+	if err != nil {
+		return result{}, err
+	}
+	res := result{
+		header: []string{"first_name", "last_name", "email", "role", "main_contact"},
+		data:   courses[0].Contacts,
+	}
+	for _, contact := range courses[0].Contacts {
+		res.rows = append(res.rows, []string{
+			contact.FirstName, contact.LastName, contact.Email, contact.Role, strconv.FormatBool(contact.MainContact),
+		})
+	}
+	return res, nil
+}
+
+func freeSlots(e *env, args []string) (result, error) {
+	fs := e.flagSet("free-slots", true)
+	rooms := fs.String("rooms", "", "comma separated TUMonline room ids (required)")
+	hours := fs.String("hours", "8-20", "hours of the day to search, e.g. 8-20")
+	duration := fs.Duration("duration", time.Hour, "minimum length of a free slot")
+	if err := fs.Parse(args); err != nil {
+		return result{}, err
+	}
+	if *rooms == "" {
+		return result{}, fmt.Errorf("-rooms is required")
+	}
+	var roomIDs []int
+	for _, room := range strings.Split(*rooms, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(room))
+		if err != nil {
+			return result{}, fmt.Errorf("invalid room id %q", room)
+		}
+		roomIDs = append(roomIDs, id)
+	}
+	daily, err := parseHours(*hours)
+	if err != nil {
+		return result{}, err
+	}
+	from, until, err := e.flags.dateRange()
+	if err != nil {
+		return result{}, err
+	}
+	c, err := e.client()
+	if err != nil {
+		return result{}, err
+	}
+	occupancy, err := c.GetRoomOccupancy(roomIDs, from, until.AddDate(0, 0, 1), daily) // until is the last day
+	if err != nil {
+		return result{}, err
+	}
+	slots := campusonline.FindFreeSlots(occupancy, *duration)
+	res := result{
+		header: []string{"room_id", "room_name", "start", "end", "duration"},
+		data:   slots,
+	}
+	for _, slot := range slots {
+		res.rows = append(res.rows, []string{
+			strconv.Itoa(slot.RoomID), slot.RoomName, slot.Start.Format(timeLayout), slot.End.Format(timeLayout),
+			slot.Duration().String(),
+		})
+	}
+	return res, nil
+}
+
+// parseHours parses hours of the day like "8-20"
+func parseHours(s string) (campusonline.DailyHours, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return campusonline.DailyHours{}, fmt.Errorf("invalid -hours %q, expected e.g. 8-20", s)
+	}
+	from, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return campusonline.DailyHours{}, fmt.Errorf("invalid -hours %q, expected e.g. 8-20", s)
+	}
+	until, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || from < 0 || until > 24 || until <= from {
+		return campusonline.DailyHours{}, fmt.Errorf("invalid -hours %q, expected e.g. 8-20", s)
+	}
+	return campusonline.DailyHours{From: time.Duration(from) * time.Hour, Until: time.Duration(until) * time.Hour}, nil
+}
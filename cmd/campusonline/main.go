@@ -0,0 +1,183 @@
+// Command campusonline queries TUMonline from the command line.
+//
+// Usage:
+//
+//	campusonline <command> [flags]
+//
+// Commands:
+//
+//	org-calendar   events of an organisation
+//	room-schedule  bookings of a room
+//	free-slots     free times of rooms
+//	course         details of a course
+//	search         search courses by title
+//	contacts       contact persons of a course
+//
+// Tokens are read from the environment variables CAMPUSONLINE_TOKEN (webservice_v1.0 api) and
+// CAMPUSONLINE_BASIC_TOKEN (wbservicesbasic api). CAMPUSONLINE_BASE_URL and CAMPUSONLINE_BASIC_BASE_URL point the
+// tool to another TUMonline instance, unset ones keep the default. Results are printed as table, json, csv or, for
+// calendars, ics.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+)
+
+const usage = `usage: campusonline <command> [flags]
+
+commands:
+  org-calendar   events of an organisation
+  room-schedule  bookings of a room
+  free-slots     free times of rooms
+  course         details of a course
+  search         search courses by title
+  contacts       contact persons of a course
+
+Run "campusonline <command> -h" for the flags of a command.
+Tokens are read from CAMPUSONLINE_TOKEN and CAMPUSONLINE_BASIC_TOKEN.
+`
+
+type command func(e *env, args []string) (result, error)
+
+var commands = map[string]command{
+	"org-calendar":  orgCalendar,
+	"room-schedule": roomSchedule,
+	"free-slots":    freeSlots,
+	"course":        course,
+	"search":        search,
+	"contacts":      contacts,
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "campusonline:", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command of args. opts are applied to the client after the configuration from the environment.
+func run(args []string, stdout io.Writer, stderr io.Writer, opts ...campusonline.Option) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		return nil
+	}
+	cmd, found := commands[args[0]]
+	if !found {
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	e := &env{stderr: stderr, flags: &commonFlags{}, opts: opts}
+	res, err := cmd(e, args[1:])
+	if err == flag.ErrHelp {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return render(stdout, e.flags.format, res)
+}
+
+// env is passed to the commands to parse the shared flags and create the client
+type env struct {
+	stderr io.Writer
+	flags  *commonFlags
+	opts   []campusonline.Option // additional client options, e.g. a transport in tests
+}
+
+// flagSet returns the flag set of a command with the shared flags registered
+func (e *env) flagSet(name string, withRange bool) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.StringVar(&e.flags.format, "format", "table", "output format: table, json, csv or ics")
+	fs.BoolVar(&e.flags.verbose, "v", false, "log requests to stderr")
+	if withRange {
+		fs.StringVar(&e.flags.from, "from", "", "first day (2006-01-02), defaults to the start of the semester")
+		fs.StringVar(&e.flags.until, "until", "", "last day (2006-01-02), defaults to the end of the semester")
+		fs.StringVar(&e.flags.semester, "semester", "", "semester, e.g. 2024W, defaults to the current semester")
+	}
+	return fs
+}
+
+// client returns a client configured from the environment. It must be called after the flags were parsed.
+func (e *env) client() (*campusonline.CampusOnline, error) {
+	opts := []campusonline.Option{
+		campusonline.WithTokenProvider(campusonline.EnvToken("CAMPUSONLINE_TOKEN")),
+		campusonline.WithBasicTokenProvider(campusonline.EnvToken("CAMPUSONLINE_BASIC_TOKEN")),
+	}
+	if base, basicBase := os.Getenv("CAMPUSONLINE_BASE_URL"), os.Getenv("CAMPUSONLINE_BASIC_BASE_URL"); base != "" || basicBase != "" {
+		opts = append(opts, campusonline.WithBaseURL(base, basicBase))
+	}
+	if e.flags.verbose {
+		opts = append(opts, campusonline.WithLogger(stderrLogger{w: e.stderr}))
+	}
+	if e.flags.lang != "" {
+		opts = append(opts, campusonline.WithLanguages(campusonline.Language(e.flags.lang)))
+	}
+	return campusonline.New("", "", append(opts, e.opts...)...)
+}
+
+// commonFlags are the flags shared by all commands
+type commonFlags struct {
+	format   string
+	verbose  bool
+	from     string
+	until    string
+	semester string
+	lang     string
+}
+
+func (f *commonFlags) semesterValue() (campusonline.Semester, error) {
+	if f.semester == "" {
+		return campusonline.CurrentSemester(), nil
+	}
+	return campusonline.ParseSemester(f.semester)
+}
+
+// dateRange resolves -from and -until, falling back to the bounds of -semester
+func (f *commonFlags) dateRange() (time.Time, time.Time, error) {
+	semester, err := f.semesterValue()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	from, until := semester.Start(), semester.End()
+	if f.from != "" {
+		if from, err = time.ParseInLocation("2006-01-02", f.from, time.Local); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid -from: %v", err)
+		}
+	}
+	if f.until != "" {
+		if until, err = time.ParseInLocation("2006-01-02", f.until, time.Local); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid -until: %v", err)
+		}
+	}
+	if until.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("-until is before -from")
+	}
+	return from, until, nil
+}
+
+// stderrLogger writes log lines like "DEBUG requesting TUMonline url=..." to w
+type stderrLogger struct {
+	w io.Writer
+}
+
+func (l stderrLogger) log(level string, msg string, args []interface{}) {
+	var sb strings.Builder
+	sb.WriteString(level + " " + msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&sb, " %v=%v", args[i], args[i+1])
+	}
+	fmt.Fprintln(l.w, sb.String())
+}
+
+func (l stderrLogger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg, args) }
+func (l stderrLogger) Info(msg string, args ...interface{})  { l.log("INFO", msg, args) }
+func (l stderrLogger) Warn(msg string, args ...interface{})  { l.log("WARN", msg, args) }
+func (l stderrLogger) Error(msg string, args ...interface{}) { l.log("ERROR", msg, args) }
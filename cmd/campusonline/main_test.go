@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+// runWithServer runs the tool with the given arguments against a fake TUMonline and returns its output
+func runWithServer(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	server := campusonlinetest.NewServer()
+	t.Cleanup(server.Close)
+	return runAgainst(t, server, args...)
+}
+
+// runAgainst runs the tool with the given arguments against the fake TUMonline and returns its output
+func runAgainst(t *testing.T, server *campusonlinetest.Server, args ...string) (string, error) {
+	t.Helper()
+	t.Setenv("CAMPUSONLINE_TOKEN", campusonlinetest.Token)
+	t.Setenv("CAMPUSONLINE_BASIC_TOKEN", campusonlinetest.BasicToken)
+	t.Setenv("CAMPUSONLINE_BASE_URL", server.BaseURL())
+	t.Setenv("CAMPUSONLINE_BASIC_BASE_URL", server.BasicBaseURL())
+	var stdout, stderr bytes.Buffer
+	err := run(args, &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestOrgCalendar(t *testing.T) {
+	out, err := runWithServer(t, "org-calendar", "-org", "TUS1200", "-semester", "2021W", "-filter", "-format", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 18 || records[0][0] != "start" {
+		t.Errorf("expected header and 17 events, got %d records", len(records))
+	}
+	if records[1][0] != "2021-10-18 10:00" || records[1][3] != "MI HS2" {
+		t.Errorf("unexpected first event %v", records[1])
+	}
+}
+
+func TestOrgCalendarCoursesICS(t *testing.T) {
+	out, err := runWithServer(t, "org-calendar", "-org", "53598", "-from", "2021-10-18", "-until", "2021-10-24",
+		"-filter", "-courses", "-format", "ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n") || strings.Count(out, "BEGIN:VEVENT") != 4 {
+		t.Errorf("unexpected calendar %s", out)
+	}
+}
+
+func TestOrgCalendarEmpty(t *testing.T) {
+	for _, courses := range []bool{false, true} {
+		args := []string{"org-calendar", "-org", "53598", "-from", "2022-08-01", "-until", "2022-08-07"}
+		if courses {
+			args = append(args, "-courses")
+		}
+		out, err := runWithServer(t, append(args, "-format", "ics")...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n") || strings.Contains(out, "BEGIN:VEVENT") {
+			t.Errorf("expected an empty calendar, got %s", out)
+		}
+		out, err = runWithServer(t, append(args, "-format", "json")...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(out) != "[]" {
+			t.Errorf("expected an empty list, got %s", out)
+		}
+	}
+}
+
+func TestRoomSchedule(t *testing.T) {
+	out, err := runWithServer(t, "room-schedule", "-room", "2300", "-semester", "2021W", "-format", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bookings []roomBooking
+	if err := json.Unmarshal([]byte(out), &bookings); err != nil {
+		t.Fatal(err)
+	}
+	if len(bookings) != 6 || bookings[0].Title != "Einführung in die Informatik" || bookings[0].Type != "A" {
+		t.Errorf("unexpected bookings %+v", bookings)
+	}
+
+	out, err = runWithServer(t, "room-schedule", "-room", "2300", "-semester", "2021W", "-type", "exam", "-format", "ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out, "BEGIN:VEVENT") != 1 || !strings.Contains(out, "SUMMARY:Klausur Analysis") {
+		t.Errorf("expected only the exam, got %s", out)
+	}
+}
+
+func TestFreeSlots(t *testing.T) {
+	out, err := runWithServer(t, "free-slots", "-rooms", "2300", "-from", "2021-10-19", "-until", "2021-10-19",
+		"-duration", "2h", "-format", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][2] != "2021-10-19 10:00" || records[1][3] != "2021-10-19 20:00" {
+		t.Errorf("expected the free afternoon, got %v", records)
+	}
+	if _, err := runWithServer(t, "free-slots", "-rooms", "2300", "-hours", "20-8"); err == nil {
+		t.Errorf("expected invalid hours to fail")
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	out, err := runWithServer(t, "search", "-q", "Informatik", "-semester", "2021W", "-org", "TUS1200", "-type", "UE",
+		"-format", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][0] != "950000004" {
+		t.Errorf("expected the exercise, got %v", records)
+	}
+}
+
+func TestCourseInEnglish(t *testing.T) {
+	out, err := runWithServer(t, "course", "-id", "950000001", "-lang", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Introduction to Informatics") || !strings.Contains(out, "Lecture") {
+		t.Errorf("unexpected course output %s", out)
+	}
+}
+
+func TestCourseAndContacts(t *testing.T) {
+	server := campusonlinetest.NewServer()
+	t.Cleanup(server.Close)
+	out, err := runAgainst(t, server, "course", "-id", "950000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "IN0001") || !strings.Contains(out, "Helmut Seidl") {
+		t.Errorf("unexpected course output %s", out)
+	}
+	if requests := server.Requests(); len(requests) != 1 {
+		t.Errorf("expected the course to be exported once, got %v", requests)
+	}
+	out, err = runWithServer(t, "contacts", "-course", "950000001", "-format", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Helmut,Seidl,seidl@in.tum.de,\"Leiter/in, Prüfer/in\",true") {
+		t.Errorf("unexpected contacts %s", out)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	out, err := runWithServer(t, "search", "-q", "Informatik", "-semester", "21W")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 4 {
+		t.Errorf("expected header and 3 results, got %q", out)
+	}
+	out, err = runWithServer(t, "search", "-q", "Informatik", "-semester", "21W", "-offset", "-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 4 {
+		t.Errorf("expected all results for a negative offset, got %q", out)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := runWithServer(t, "unknown"); err == nil {
+		t.Error("expected error for unknown command")
+	}
+	if _, err := runWithServer(t, "org-calendar"); err == nil {
+		t.Error("expected error for missing -org")
+	}
+	if _, err := runWithServer(t, "search", "-q", "x", "-format", "ics"); err == nil {
+		t.Error("expected error for ics output of search")
+	}
+	if _, err := runWithServer(t, "course", "-h"); err != nil {
+		t.Errorf("expected help without error, got %v", err)
+	}
+}
+
+// hostRecorder fails all requests and remembers their hosts
+type hostRecorder struct {
+	hosts []string
+}
+
+func (r *hostRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.hosts = append(r.hosts, req.URL.Host)
+	return nil, errors.New("offline")
+}
+
+func TestSingleBaseURL(t *testing.T) {
+	server := campusonlinetest.NewServer()
+	t.Cleanup(server.Close)
+	t.Setenv("CAMPUSONLINE_TOKEN", campusonlinetest.Token)
+	t.Setenv("CAMPUSONLINE_BASIC_TOKEN", campusonlinetest.BasicToken)
+	t.Setenv("CAMPUSONLINE_BASE_URL", server.BaseURL())
+	t.Setenv("CAMPUSONLINE_BASIC_BASE_URL", "")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"room-schedule", "-room", "2300", "-semester", "2021W"}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+
+	// the wbservicesbasic api keeps its default
+	recorder := &hostRecorder{}
+	if err := run([]string{"search", "-q", "x"}, &stdout, &stderr, campusonline.WithTransport(recorder)); err == nil {
+		t.Error("expected error of the offline transport")
+	}
+	if len(recorder.hosts) == 0 || recorder.hosts[0] != "campus.tum.de" {
+		t.Errorf("expected requests to the default TUMonline, got %v", recorder.hosts)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+)
+
+// result is the output of a subcommand in all supported formats
+type result struct {
+	header   []string
+	rows     [][]string
+	data     interface{}             // written as json
+	calendar bool                    // whether the result can be written as ics
+	events   []campusonline.ICSEvent // written as ics
+	name     string                  // calendar name for ics output
+}
+
+func render(w io.Writer, format string, res result) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		writeTabbed(tw, res.header)
+		for _, row := range res.rows {
+			writeTabbed(tw, row)
+		}
+		return tw.Flush()
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(res.header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(res.rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res.data)
+	case "ics":
+		if !res.calendar {
+			return fmt.Errorf("ics output is not supported for this command")
+		}
+		return campusonline.WriteICS(w, res.name, res.events)
+	}
+	return fmt.Errorf("unknown format %q, expected table, json, csv or ics", format)
+}
+
+func writeTabbed(w io.Writer, cells []string) {
+	for i, cell := range cells {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, cell)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,159 @@
+package campusonline
+
+import (
+	"sort"
+)
+
+// ConflictKind is the reason two events must not overlap
+type ConflictKind string
+
+const (
+	ConflictRoom       ConflictKind = "room"       // both events are in the same room
+	ConflictLecturer   ConflictKind = "lecturer"   // a person is a contact of both courses
+	ConflictCurriculum ConflictKind = "curriculum" // both courses are part of the same curriculum
+)
+
+// Conflict is a pair of overlapping events that must not overlap
+type Conflict struct {
+	Kind ConflictKind `json:"kind"`
+	// Subject is the room name, the PersonID or the curriculum name the events clash in
+	Subject string      `json:"subject"`
+	Person  *Person     `json:"person,omitempty"` // the person of lecturer conflicts
+	First   CourseEvent `json:"first"`            // the event starting first
+	Second  CourseEvent `json:"second"`
+	Overlap Interval    `json:"overlap"`
+}
+
+// Curriculum is a set of courses students attend together, e.g. the courses of the first semester of a degree
+// program. TUMonline's calendars don't contain curricula, so they have to be given.
+type Curriculum struct {
+	Name      string `json:"name"`
+	CourseIDs []int  `json:"course_ids"`
+}
+
+// FindConflicts returns all room, lecturer and curriculum conflicts of the courses ordered by the start of the
+// overlap. Lecturer conflicts need contacts loaded with LoadCourseContacts.
+func FindConflicts(courses []Course, curricula ...Curriculum) []Conflict {
+	res := RoomConflicts(courses)
+	res = append(res, LecturerConflicts(courses)...)
+	res = append(res, CurriculumConflicts(courses, curricula)...)
+	sortConflicts(res)
+	return res
+}
+
+// RoomConflicts returns the overlapping events of courses in the same room, ordered by the start of the overlap
+func RoomConflicts(courses []Course) []Conflict {
+	byRoom := map[string][]CourseEvent{}
+	for _, course := range courses {
+		for _, event := range course.Events {
+			if event.RoomName != "" {
+				byRoom[event.RoomName] = append(byRoom[event.RoomName], courseEvent(course, event))
+			}
+		}
+	}
+	var res []Conflict
+	for room, events := range byRoom {
+		res = append(res, overlapping(ConflictRoom, room, events, false)...)
+	}
+	sortConflicts(res)
+	return res
+}
+
+// LecturerConflicts returns the overlapping events of different courses sharing a contact person, ordered by the start
+// of the overlap. Contacts without PersonID are ignored.
+func LecturerConflicts(courses []Course) []Conflict {
+	index := NewPersonIndex(courses)
+	var res []Conflict
+	for _, person := range index.People().All() {
+		var events []CourseEvent
+		for _, course := range index.CoursesByPerson(person.PersonID) {
+			for _, event := range course.Events {
+				events = append(events, courseEvent(course, event))
+			}
+		}
+		for _, conflict := range overlapping(ConflictLecturer, person.PersonID, events, true) {
+			p := person
+			conflict.Person = &p
+			res = append(res, conflict)
+		}
+	}
+	sortConflicts(res)
+	return res
+}
+
+// CurriculumConflicts returns the overlapping events of different courses of the same curriculum, ordered by the
+// start of the overlap
+func CurriculumConflicts(courses []Course, curricula []Curriculum) []Conflict {
+	byID := map[int]Course{}
+	for _, course := range courses {
+		byID[course.CourseID] = course
+	}
+	var res []Conflict
+	for _, curriculum := range curricula {
+		var events []CourseEvent
+		for _, id := range curriculum.CourseIDs {
+			for _, event := range byID[id].Events {
+				events = append(events, courseEvent(byID[id], event))
+			}
+		}
+		res = append(res, overlapping(ConflictCurriculum, curriculum.Name, events, true)...)
+	}
+	sortConflicts(res)
+	return res
+}
+
+func courseEvent(course Course, event Event) CourseEvent {
+	return CourseEvent{Event: event, CourseID: course.CourseID, CourseTitle: course.Title}
+}
+
+// overlapping returns a conflict for each pair of overlapping events. Events listed more than once, e.g. because two
+// courses share them, are only considered once. If acrossCourses is set, events of the same course don't conflict.
+func overlapping(kind ConflictKind, subject string, events []CourseEvent, acrossCourses bool) []Conflict {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	var res []Conflict
+	var active []CourseEvent
+	seen := map[string]bool{}
+	for _, event := range events {
+		if event.EventID != "" {
+			if seen[event.EventID] {
+				continue
+			}
+			seen[event.EventID] = true
+		}
+		running := active[:0]
+		for _, a := range active {
+			if a.End.After(event.Start) {
+				running = append(running, a)
+			}
+		}
+		active = running
+		for _, a := range active {
+			if acrossCourses && a.CourseID == event.CourseID {
+				continue
+			}
+			res = append(res, Conflict{
+				Kind:    kind,
+				Subject: subject,
+				First:   a,
+				Second:  event,
+				Overlap: Interval{Start: event.Start, End: earliest(a.End, event.End)},
+			})
+		}
+		if event.Start.Before(event.End) {
+			active = append(active, event)
+		}
+	}
+	return res
+}
+
+func sortConflicts(conflicts []Conflict) {
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		if !conflicts[i].Overlap.Start.Equal(conflicts[j].Overlap.Start) {
+			return conflicts[i].Overlap.Start.Before(conflicts[j].Overlap.Start)
+		}
+		if conflicts[i].Kind != conflicts[j].Kind {
+			return conflicts[i].Kind < conflicts[j].Kind
+		}
+		return conflicts[i].Subject < conflicts[j].Subject
+	})
+}
@@ -0,0 +1,77 @@
+package campusonline
+
+import (
+	"testing"
+)
+
+func conflictTestCourses() []Course {
+	seidl := ContactPerson{PersonID: "A1B2C3D4E5F6", FirstName: "Helmut", LastName: "Seidl"}
+	petter := ContactPerson{PersonID: "F6E5D4C3B2A1", FirstName: "Michael", LastName: "Petter"}
+	return []Course{
+		{CourseID: 1, Title: "Informatik 1", Contacts: []ContactPerson{seidl}, Events: []Event{
+			{EventID: "a", RoomName: "MI HS1", Start: at(18, 10, 0), End: at(18, 12, 0)},
+			{EventID: "b", RoomName: "MI HS1", Start: at(19, 10, 0), End: at(19, 12, 0)},
+		}},
+		{CourseID: 2, Title: "Informatik 1 (Übung)", Contacts: []ContactPerson{petter}, Events: []Event{
+			{EventID: "c", RoomName: "MI HS1", Start: at(18, 11, 0), End: at(18, 13, 0)}, // room clash with a
+			{EventID: "d", RoomName: "MI HS2", Start: at(18, 10, 0), End: at(18, 11, 0)}, // clashes with a in curriculum
+			{EventID: "d", RoomName: "MI HS2", Start: at(18, 10, 0), End: at(18, 11, 0)}, // listed twice
+		}},
+		{CourseID: 3, Title: "Compilerbau", Contacts: []ContactPerson{seidl, petter}, Events: []Event{
+			{EventID: "e", RoomName: "MW 0001", Start: at(19, 11, 0), End: at(19, 13, 0)}, // seidl clashes with b
+			{EventID: "f", RoomName: "MW 0001", Start: at(19, 12, 0), End: at(19, 14, 0)}, // same course as e
+		}},
+	}
+}
+
+func TestRoomConflicts(t *testing.T) {
+	conflicts := RoomConflicts(conflictTestCourses())
+	if len(conflicts) != 2 {
+		t.Fatalf("expected 2 room conflicts, got %+v", conflicts)
+	}
+	c := conflicts[0]
+	if c.Kind != ConflictRoom || c.Subject != "MI HS1" || c.First.EventID != "a" || c.Second.EventID != "c" ||
+		!c.Overlap.Start.Equal(at(18, 11, 0)) || !c.Overlap.End.Equal(at(18, 12, 0)) {
+		t.Errorf("unexpected conflict %+v", c)
+	}
+	// events of the same course double-booking a room are reported, too
+	if c := conflicts[1]; c.Subject != "MW 0001" || c.First.EventID != "e" || c.Second.EventID != "f" {
+		t.Errorf("unexpected conflict %+v", c)
+	}
+}
+
+func TestLecturerConflicts(t *testing.T) {
+	conflicts := LecturerConflicts(conflictTestCourses())
+	// petter's courses are on different days, seidl's lecture on the 19th clashes with compilerbau but e and f don't
+	// conflict as they belong to the same course
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 lecturer conflict, got %+v", conflicts)
+	}
+	c := conflicts[0]
+	if c.Kind != ConflictLecturer || c.Subject != "A1B2C3D4E5F6" || c.Person == nil || c.Person.LastName != "Seidl" ||
+		c.First.EventID != "b" || c.Second.EventID != "e" {
+		t.Errorf("unexpected conflict %+v", c)
+	}
+}
+
+func TestCurriculumConflicts(t *testing.T) {
+	curricula := []Curriculum{{Name: "Informatik 1. Semester", CourseIDs: []int{1, 2}}}
+	conflicts := CurriculumConflicts(conflictTestCourses(), curricula)
+	if len(conflicts) != 2 {
+		t.Fatalf("expected 2 curriculum conflicts, got %+v", conflicts)
+	}
+	// d is listed twice but reported once
+	if c := conflicts[0]; c.Subject != "Informatik 1. Semester" || c.First.EventID != "a" || c.Second.EventID != "d" {
+		t.Errorf("unexpected conflict %+v", c)
+	}
+
+	all := FindConflicts(conflictTestCourses(), curricula...)
+	if len(all) != 5 {
+		t.Errorf("expected 5 conflicts, got %d", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Overlap.Start.Before(all[i-1].Overlap.Start) {
+			t.Errorf("expected conflicts ordered by start")
+		}
+	}
+}
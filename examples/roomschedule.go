@@ -3,24 +3,24 @@ package main
 import (
 	"fmt"
 	campusonline "github.com/RBG-TUM/CAMPUSOnline"
-	"time"
 )
 
 func main() {
 	co, _ := campusonline.New("xxx", "xxx")
-	roomstuff, _ := co.GetXCalOrgIN(
-		time.Date(2021, 10, 1, 0, 0, 0, 0, time.Local),
-		time.Date(2022, 3, 31, 23, 59, 59, 0, time.Local),
-	)
+	roomstuff, err := co.GetXCalOrgSemester(campusonline.Semester{Year: 2021, Term: campusonline.Winter}, campusonline.CsOrgId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	ical := &roomstuff
 	fmt.Println(len(roomstuff.Vcalendar.Events))
 	ical.Filter()
 	ical.Sort()
 	fmt.Println(len(roomstuff.Vcalendar.Events))
 	courses := ical.GroupByCourse()
-	courses, err := co.LoadCourseContacts(courses)
+	courses, err = co.LoadCourseContacts(courses)
 	if err != nil {
-		println(err)
+		fmt.Println(err)
 	}
 	println(len(courses))
 	for _, course := range courses {
@@ -0,0 +1,380 @@
+// Package feed serves TUMonline calendars as iCalendar subscriptions, so calendar apps poll the feed instead of
+// TUMonline. Feeds are regenerated at most once per TTL and support conditional requests with ETag and
+// Last-Modified.
+//
+// Feeds are served at
+//
+//	/courses/{id}.ics
+//	/rooms/{id}.ics
+//	/orgs/{id or code}.ics
+//
+// relative to where the handler is mounted. All feeds cover the current semester unless a semester is requested,
+// e.g. ?semester=2024W. ?filter=true applies ICalendar.Filter to course and organisation feeds.
+//
+// Calendar apps can't authenticate, so handlers created WithSecret only serve feeds whose url carries the token
+// returned by URL, which authorizes exactly that feed and query.
+package feed
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+)
+
+// Handler serves the feeds
+type Handler struct {
+	co         *campusonline.CampusOnline
+	orgs       []int
+	ttl        time.Duration
+	secret     []byte
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// WithOrgs sets the organisations whose calendars are searched for course feeds.
+// Defaults to computer science and computer engineering.
+func WithOrgs(orgIDs ...int) Option {
+	return func(h *Handler) {
+		h.orgs = orgIDs
+	}
+}
+
+// WithTTL sets how long a generated feed is served before it's regenerated. Defaults to 15 minutes.
+func WithTTL(ttl time.Duration) Option {
+	return func(h *Handler) {
+		h.ttl = ttl
+	}
+}
+
+// WithMaxEntries limits how many feeds and calendars are kept in memory. Defaults to 1000.
+func WithMaxEntries(n int) Option {
+	return func(h *Handler) {
+		h.maxEntries = n
+	}
+}
+
+// WithSecret only serves feeds whose url was signed with the secret by URL. Without a secret all feeds are public.
+func WithSecret(secret []byte) Option {
+	return func(h *Handler) {
+		h.secret = secret
+	}
+}
+
+// NewHandler returns a handler serving feeds of the calendars c has access to
+func NewHandler(c *campusonline.CampusOnline, opts ...Option) *Handler {
+	h := &Handler{
+		co:         c,
+		orgs:       []int{campusonline.CsOrgId, campusonline.CeOrgId},
+		ttl:        time.Minute * 15,
+		maxEntries: 1000,
+		now:        time.Now,
+		entries:    map[string]*entry{},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// entry caches a value and regenerates it once it expired. Concurrent requests for an expired entry wait for a
+// single regeneration.
+type entry struct {
+	used time.Time // guarded by Handler.mu
+
+	mu      sync.Mutex
+	value   interface{}
+	expires time.Time
+}
+
+// maxIdle is how long entries are kept without being requested. Feeds polled less often lose their stale copy and
+// Last-Modified.
+const maxIdle = 24 * time.Hour
+
+// cached returns the value stored under key, calling generate with the previous value (or nil) once it expired.
+// If generate fails the previous value is served for another ttl, so feeds survive TUMonline outages.
+func (h *Handler) cached(key string, generate func(prev interface{}) (interface{}, error)) (interface{}, error) {
+	h.mu.Lock()
+	e, found := h.entries[key]
+	if !found {
+		h.evict()
+		e = &entry{}
+		h.entries[key] = e
+	}
+	e.used = h.now()
+	h.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := h.now()
+	if e.value != nil && now.Before(e.expires) {
+		return e.value, nil
+	}
+	value, err := generate(e.value)
+	if err != nil && e.value == nil {
+		h.mu.Lock()
+		if h.entries[key] == e {
+			delete(h.entries, key) // don't keep entries for feeds that don't exist
+		}
+		h.mu.Unlock()
+		return nil, err
+	}
+	if err == nil {
+		e.value = value
+	}
+	e.expires = now.Add(h.ttl)
+	return e.value, nil
+}
+
+// URL returns the url of the feed at path, e.g. "/rooms/2300.ics", relative to where the handler is mounted. If the
+// handler has a secret, the query gets a token that authorizes exactly this path and query.
+func (h *Handler) URL(path string, query url.Values) string {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Del("token")
+	if h.secret != nil {
+		q.Set("token", h.token(path, q))
+	}
+	res := "/" + strings.Trim(path, "/")
+	if len(q) != 0 {
+		res += "?" + q.Encode()
+	}
+	return res
+}
+
+// token signs the path and the query, which must not contain the token
+func (h *Handler) token(path string, query url.Values) string {
+	mac := hmac.New(sha256.New, h.secret)
+	fmt.Fprintf(mac, "/%s?%s", strings.Trim(path, "/"), query.Encode())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	if h.secret == nil {
+		return true
+	}
+	query := r.URL.Query()
+	token := query.Get("token")
+	query.Del("token")
+	return hmac.Equal([]byte(token), []byte(h.token(r.URL.Path, query)))
+}
+
+// evict drops the entries that weren't requested for maxIdle and, if there are still too many, the least recently
+// requested ones to make room for a new entry. h.mu must be held.
+func (h *Handler) evict() {
+	now := h.now()
+	for key, e := range h.entries {
+		if now.Sub(e.used) > maxIdle {
+			delete(h.entries, key)
+		}
+	}
+	for len(h.entries) > 0 && len(h.entries) >= h.maxEntries {
+		var oldest string
+		for key, e := range h.entries {
+			if oldest == "" || e.used.Before(h.entries[oldest].used) {
+				oldest = key
+			}
+		}
+		delete(h.entries, oldest)
+	}
+}
+
+// rendered is a generated feed
+type rendered struct {
+	body     []byte
+	etag     string
+	modified time.Time
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(r) {
+		http.Error(w, "missing or invalid feed token", http.StatusForbidden)
+		return
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	segments := strings.Split(strings.TrimSuffix(path, ".ics"), "/")
+	if !strings.HasSuffix(path, ".ics") || len(segments) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	semester := campusonline.CurrentSemester()
+	if param := r.URL.Query().Get("semester"); param != "" {
+		var err error
+		if semester, err = campusonline.ParseSemester(param); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	filter := r.URL.Query().Get("filter") == "true"
+
+	var generate func() (string, []campusonline.ICSEvent, error)
+	switch segments[0] {
+	case "courses":
+		courseID, err := strconv.Atoi(segments[1])
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		generate = func() (string, []campusonline.ICSEvent, error) {
+			return h.courseFeed(courseID, semester, filter)
+		}
+	case "rooms":
+		roomID, err := strconv.Atoi(segments[1])
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		generate = func() (string, []campusonline.ICSEvent, error) {
+			return h.roomFeed(roomID, semester)
+		}
+	case "orgs":
+		generate = func() (string, []campusonline.ICSEvent, error) {
+			return h.orgFeed(segments[1], semester, filter)
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	key := fmt.Sprintf("feed/%s/%s/%s/%t", segments[0], segments[1], semester, filter)
+	value, err := h.cached(key, func(prev interface{}) (interface{}, error) {
+		return h.render(prev, generate)
+	})
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, errNotFound) {
+			status = http.StatusNotFound
+		}
+		var statusErr *campusonline.StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	feed := value.(*rendered)
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("ETag", feed.etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(h.ttl.Seconds())))
+	http.ServeContent(w, r, segments[1]+".ics", feed.modified, bytes.NewReader(feed.body))
+}
+
+var errNotFound = errors.New("not found")
+
+// render generates the feed. Last-Modified only changes if the events changed since prev was rendered.
+func (h *Handler) render(prev interface{}, generate func() (string, []campusonline.ICSEvent, error)) (interface{}, error) {
+	name, events, err := generate()
+	if err != nil {
+		return nil, err
+	}
+	// TUMonline's DTSTAMPs aren't reliable, so the events are hashed without them and stamped with the time they
+	// last changed instead
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n", name)
+	for i := range events {
+		events[i].Stamp = time.Time{}
+		fmt.Fprintf(hash, "%+v\n", events[i])
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+	if prev, ok := prev.(*rendered); ok && prev.etag == etag {
+		return prev, nil
+	}
+	modified := h.now().UTC().Truncate(time.Second)
+	for i := range events {
+		events[i].Stamp = modified
+	}
+	var body bytes.Buffer
+	if err := campusonline.WriteICS(&body, name, events); err != nil {
+		return nil, err
+	}
+	return &rendered{body: body.Bytes(), etag: etag, modified: modified}, nil
+}
+
+// calendar returns the organisation's calendar for the semester, cached so course feeds share it
+func (h *Handler) calendar(orgID int, semester campusonline.Semester) (campusonline.ICalendar, error) {
+	value, err := h.cached(fmt.Sprintf("calendar/%d/%s", orgID, semester), func(interface{}) (interface{}, error) {
+		return h.co.GetXCalOrgSemester(semester, orgID)
+	})
+	if err != nil {
+		return campusonline.ICalendar{}, err
+	}
+	return value.(campusonline.ICalendar), nil
+}
+
+func (h *Handler) courseFeed(courseID int, semester campusonline.Semester, filter bool) (string, []campusonline.ICSEvent, error) {
+	suffix := "course/" + strconv.Itoa(courseID)
+	for _, orgID := range h.orgs {
+		cal, err := h.calendar(orgID, semester)
+		if err != nil {
+			return "", nil, err
+		}
+		if filter {
+			cal.Filter()
+		}
+		var events []campusonline.ICSEvent
+		for _, event := range cal.ICSEvents() {
+			if strings.HasSuffix(event.URL, suffix) {
+				events = append(events, event)
+			}
+		}
+		if len(events) != 0 {
+			return events[0].Summary, events, nil
+		}
+	}
+	return "", nil, fmt.Errorf("course %d: %w", courseID, errNotFound)
+}
+
+func (h *Handler) roomFeed(roomID int, semester campusonline.Semester) (string, []campusonline.ICSEvent, error) {
+	rdm, err := h.co.GetRoomSchedule(roomID, semester.Start(), semester.End())
+	if err != nil {
+		return "", nil, err
+	}
+	events := rdm.ICSEvents()
+	name := fmt.Sprintf("Room %d", roomID)
+	if len(events) != 0 && events[0].Location != "" {
+		name = events[0].Location
+	}
+	return name, events, nil
+}
+
+func (h *Handler) orgFeed(ref string, semester campusonline.Semester, filter bool) (string, []campusonline.ICSEvent, error) {
+	orgs, err := h.co.GetOrganisations()
+	if err != nil {
+		return "", nil, err
+	}
+	org, found := orgs.Lookup(ref)
+	if !found {
+		return "", nil, fmt.Errorf("organisation %s: %w", ref, errNotFound)
+	}
+	cal, err := h.calendar(org.ID, semester)
+	if err != nil {
+		return "", nil, err
+	}
+	if filter {
+		cal.Filter()
+	}
+	return org.Name, cal.ICSEvents(), nil
+}
@@ -0,0 +1,197 @@
+package feed
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+// newTestHandler returns a handler for a fake TUMonline whose clock is advanced by setting *now
+func newTestHandler(t *testing.T) (*httptest.Server, *campusonlinetest.Server, *time.Time) {
+	t.Helper()
+	fake := campusonlinetest.NewServer()
+	t.Cleanup(fake.Close)
+	c, err := campusonline.New(campusonlinetest.Token, campusonlinetest.BasicToken,
+		campusonline.WithBaseURL(fake.BaseURL(), fake.BasicBaseURL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+	h := NewHandler(c, WithTTL(time.Minute))
+	h.now = func() time.Time { return now }
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	return ts, fake, &now
+}
+
+func get(t *testing.T, url string, header http.Header) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, string(body)
+}
+
+func TestFeeds(t *testing.T) {
+	ts, _, _ := newTestHandler(t)
+	for path, events := range map[string]int{
+		"/courses/950000001.ics?semester=2021W":             7,
+		"/courses/950000001.ics?semester=2021W&filter=true": 6,
+		"/courses/950000010.ics?semester=2021W":             1, // computer engineering
+		"/rooms/2300.ics?semester=2021W":                    6,
+		"/orgs/TUS1200.ics?semester=2021W":                  20,
+		"/orgs/53598.ics?semester=2021W&filter=true":        17,
+	} {
+		res, body := get(t, ts.URL+path, nil)
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d: %s", path, res.StatusCode, body)
+			continue
+		}
+		if res.Header.Get("Content-Type") != "text/calendar; charset=utf-8" || res.Header.Get("ETag") == "" {
+			t.Errorf("%s: unexpected headers %v", path, res.Header)
+		}
+		if n := strings.Count(body, "BEGIN:VEVENT"); n != events {
+			t.Errorf("%s: expected %d events, got %d", path, events, n)
+		}
+	}
+}
+
+func TestFeedNotFound(t *testing.T) {
+	ts, _, _ := newTestHandler(t)
+	for _, path := range []string{"/courses/1.ics", "/rooms/1.ics", "/orgs/TUS9999.ics", "/courses/950000001", "/other/1.ics"} {
+		if res, _ := get(t, ts.URL+path+"?semester=2021W", nil); res.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", path, res.StatusCode)
+		}
+	}
+}
+
+func TestSignedFeeds(t *testing.T) {
+	fake := campusonlinetest.NewServer()
+	t.Cleanup(fake.Close)
+	c, err := campusonline.New(campusonlinetest.Token, campusonlinetest.BasicToken,
+		campusonline.WithBaseURL(fake.BaseURL(), fake.BasicBaseURL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(c, WithSecret([]byte("secret")))
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	signed := h.URL("/rooms/2300.ics", url.Values{"semester": {"2021W"}})
+	if res, body := get(t, ts.URL+signed, nil); res.StatusCode != http.StatusOK {
+		t.Errorf("expected signed feed, got %d: %s", res.StatusCode, body)
+	}
+	for _, path := range []string{
+		"/rooms/2300.ics?semester=2021W",
+		"/rooms/2300.ics?semester=2021W&token=0123",
+		strings.Replace(signed, "2300", "2301", 1),
+		strings.Replace(signed, "2021W", "2022S", 1),
+		signed + "&filter=true",
+	} {
+		if res, _ := get(t, ts.URL+path, nil); res.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: expected status 403, got %d", path, res.StatusCode)
+		}
+	}
+	if requests := fake.Requests(); len(requests) != 1 {
+		t.Errorf("expected only the signed feed to be fetched, got %v", requests)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	ts, fake, now := newTestHandler(t)
+	url := ts.URL + "/rooms/2300.ics?semester=2021W"
+	res, body := get(t, url, nil)
+	etag, modified := res.Header.Get("ETag"), res.Header.Get("Last-Modified")
+	if modified != "Mon, 01 Nov 2021 12:00:00 GMT" {
+		t.Errorf("unexpected Last-Modified %q", modified)
+	}
+
+	res, _ = get(t, url, http.Header{"If-None-Match": {etag}})
+	if res.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 for matching etag, got %d", res.StatusCode)
+	}
+	res, _ = get(t, url, http.Header{"If-Modified-Since": {modified}})
+	if res.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 for unchanged feed, got %d", res.StatusCode)
+	}
+
+	// regenerating an unchanged feed keeps etag and Last-Modified
+	requests := len(fake.Requests())
+	*now = now.Add(time.Minute * 2)
+	res, again := get(t, url, nil)
+	if len(fake.Requests()) != requests+1 {
+		t.Errorf("expected the feed to be regenerated after the ttl")
+	}
+	if res.Header.Get("ETag") != etag || res.Header.Get("Last-Modified") != modified || again != body {
+		t.Errorf("expected unchanged feed to keep its etag and Last-Modified")
+	}
+
+	// changed events get a new etag
+	rdm := strings.Replace(string(campusonlinetest.Fixture("rdm_2300.xml")), "20211019T083000", "20211019T090000", 1)
+	fake.SetRoomSchedule(2300, []byte(rdm))
+	*now = now.Add(time.Minute * 2)
+	res, _ = get(t, url, http.Header{"If-None-Match": {etag}})
+	if res.StatusCode != http.StatusOK || res.Header.Get("ETag") == etag {
+		t.Errorf("expected changed feed with new etag, got %d %s", res.StatusCode, res.Header.Get("ETag"))
+	}
+}
+
+func TestStaleFeedOnFailure(t *testing.T) {
+	ts, fake, now := newTestHandler(t)
+	url := ts.URL + "/orgs/53598.ics?semester=2021W"
+	_, body := get(t, url, nil)
+	fake.Fail(campusonlinetest.EndpointXCalOrg, http.StatusInternalServerError)
+	*now = now.Add(time.Minute * 2)
+	res, stale := get(t, url, nil)
+	if res.StatusCode != http.StatusOK || stale != body {
+		t.Errorf("expected the last feed while TUMonline fails, got %d", res.StatusCode)
+	}
+	if res, _ := get(t, ts.URL+"/orgs/53599.ics?semester=2021W", nil); res.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected 502 for a feed that was never generated, got %d", res.StatusCode)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	now := time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+	h := NewHandler(nil, WithMaxEntries(2))
+	h.now = func() time.Time { return now }
+	request := func(key string) {
+		t.Helper()
+		if _, err := h.cached(key, func(interface{}) (interface{}, error) { return key, nil }); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Second)
+	}
+	request("a")
+	request("b")
+	request("a")
+	request("c")
+	if _, found := h.entries["b"]; found || len(h.entries) != 2 {
+		t.Errorf("expected the least recently requested entry to be evicted, got %v", h.entries)
+	}
+	now = now.Add(maxIdle)
+	request("d")
+	if _, found := h.entries["d"]; !found || len(h.entries) != 1 {
+		t.Errorf("expected idle entries to be evicted, got %v", h.entries)
+	}
+}
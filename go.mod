@@ -2,7 +2,10 @@ module github.com/RBG-TUM/CAMPUSOnline
 
 go 1.17
 
-require github.com/dgraph-io/ristretto v0.1.0
+require (
+	github.com/dgraph-io/ristretto v0.1.0
+	github.com/mattn/go-sqlite3 v1.14.17
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.1.1 // indirect
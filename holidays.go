@@ -0,0 +1,119 @@
+package campusonline
+
+import (
+	"sort"
+	"time"
+)
+
+// Holiday is a day on which no lectures take place
+type Holiday struct {
+	Date time.Time `json:"date"`
+	Name string    `json:"name"`
+	// Public is true for bavarian public holidays and false for days of TUM's lecture-free periods
+	Public bool `json:"public"`
+}
+
+// PublicHolidays returns the public holidays of year observed in Munich and Garching
+func PublicHolidays(year int) []Holiday {
+	easter := easterSunday(year)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+	}
+	return []Holiday{
+		{Date: day(time.January, 1), Name: "Neujahr", Public: true},
+		{Date: day(time.January, 6), Name: "Heilige Drei Könige", Public: true},
+		{Date: easter.AddDate(0, 0, -2), Name: "Karfreitag", Public: true},
+		{Date: easter.AddDate(0, 0, 1), Name: "Ostermontag", Public: true},
+		{Date: day(time.May, 1), Name: "Tag der Arbeit", Public: true},
+		{Date: easter.AddDate(0, 0, 39), Name: "Christi Himmelfahrt", Public: true},
+		{Date: easter.AddDate(0, 0, 50), Name: "Pfingstmontag", Public: true},
+		{Date: easter.AddDate(0, 0, 60), Name: "Fronleichnam", Public: true},
+		{Date: day(time.August, 15), Name: "Mariä Himmelfahrt", Public: true},
+		{Date: day(time.October, 3), Name: "Tag der Deutschen Einheit", Public: true},
+		{Date: day(time.November, 1), Name: "Allerheiligen", Public: true},
+		{Date: day(time.December, 25), Name: "1. Weihnachtstag", Public: true},
+		{Date: day(time.December, 26), Name: "2. Weihnachtstag", Public: true},
+	}
+}
+
+// LectureFreeDays returns all days of the lecture period of s without lectures: public holidays, the christmas
+// break in winter and the pentecost break in summer. Every day is only contained once, public holidays take
+// precedence over breaks.
+func (s Semester) LectureFreeDays() []Holiday {
+	start, end := s.LectureStart(), s.LectureEnd()
+	days := map[string]Holiday{}
+	add := func(h Holiday) {
+		if h.Date.Before(start) || h.Date.After(end) {
+			return
+		}
+		key := h.Date.Format("2006-01-02")
+		if existing, found := days[key]; found && existing.Public {
+			return
+		}
+		days[key] = h
+	}
+	if s.Term == Winter {
+		christmas := time.Date(s.Year, time.December, 24, 0, 0, 0, 0, time.Local)
+		for d := christmas; !d.After(time.Date(s.Year+1, time.January, 6, 0, 0, 0, 0, time.Local)); d = d.AddDate(0, 0, 1) {
+			add(Holiday{Date: d, Name: "Weihnachtsferien"})
+		}
+	} else {
+		whitMonday := easterSunday(s.Year).AddDate(0, 0, 50)
+		for i := 1; i <= 5; i++ {
+			add(Holiday{Date: whitMonday.AddDate(0, 0, i), Name: "Pfingstferien"})
+		}
+	}
+	for year := start.Year(); year <= end.Year(); year++ {
+		for _, h := range PublicHolidays(year) {
+			add(h)
+		}
+	}
+	var res []Holiday
+	for _, h := range days {
+		res = append(res, h)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Date.Before(res[j].Date) })
+	return res
+}
+
+// IsLectureFree reports whether t lies on a public holiday or in a lecture-free break of the lecture period.
+// Days outside the lecture period are not considered.
+func IsLectureFree(t time.Time) (Holiday, bool) {
+	return lectureFreeDays{}.lookup(t)
+}
+
+// lectureFreeDays are the lecture-free days by semester and date, each semester is computed on its first lookup
+type lectureFreeDays map[Semester]map[string]Holiday
+
+func (d lectureFreeDays) lookup(t time.Time) (Holiday, bool) {
+	t = t.In(time.Local)
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	semester := SemesterOf(date)
+	days, found := d[semester]
+	if !found {
+		days = map[string]Holiday{}
+		for _, h := range semester.LectureFreeDays() {
+			days[h.Date.Format("2006-01-02")] = h
+		}
+		d[semester] = days
+	}
+	h, found := days[date.Format("2006-01-02")]
+	return h, found
+}
+
+// MarkLectureFree sets LectureFree on all events of the courses that take place on lecture-free days and returns
+// the number of events marked.
+func MarkLectureFree(courses []Course) int {
+	marked := 0
+	days := lectureFreeDays{}
+	for i := range courses {
+		for j := range courses[i].Events {
+			_, lectureFree := days.lookup(courses[i].Events[j].Start)
+			courses[i].Events[j].LectureFree = lectureFree
+			if lectureFree {
+				marked++
+			}
+		}
+	}
+	return marked
+}
@@ -0,0 +1,107 @@
+package campusonline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPublicHolidays(t *testing.T) {
+	holidays := PublicHolidays(2024)
+	if len(holidays) != 13 {
+		t.Fatalf("expected 13 holidays, got %d", len(holidays))
+	}
+	expected := map[string]string{
+		"Karfreitag":          "2024-03-29",
+		"Ostermontag":         "2024-04-01",
+		"Christi Himmelfahrt": "2024-05-09",
+		"Pfingstmontag":       "2024-05-20",
+		"Fronleichnam":        "2024-05-30",
+	}
+	for _, h := range holidays {
+		if date, found := expected[h.Name]; found && h.Date.Format("2006-01-02") != date {
+			t.Errorf("%s: expected %s, got %s", h.Name, date, h.Date.Format("2006-01-02"))
+		}
+		if !h.Public {
+			t.Errorf("%s is not marked public", h.Name)
+		}
+	}
+}
+
+func TestLectureFreeDays(t *testing.T) {
+	days := Semester{2024, Summer}.LectureFreeDays()
+	var dates []string
+	for _, h := range days {
+		dates = append(dates, h.Date.Format("01-02"))
+	}
+	expected := []string{"05-01", "05-09", "05-20", "05-21", "05-22", "05-23", "05-24", "05-25", "05-30"}
+	if len(dates) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, dates)
+	}
+	for i := range expected {
+		if dates[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, dates)
+			break
+		}
+	}
+
+	winter := Semester{2024, Winter}.LectureFreeDays()
+	if len(winter) != 15 { // allerheiligen and the christmas break
+		t.Errorf("expected 15 lecture-free days, got %d", len(winter))
+	}
+}
+
+func TestIsLectureFree(t *testing.T) {
+	tests := []struct {
+		time     time.Time
+		expected string
+	}{
+		{time.Date(2021, 11, 1, 10, 0, 0, 0, time.Local), "Allerheiligen"},
+		{time.Date(2021, 12, 28, 10, 0, 0, 0, time.Local), "Weihnachtsferien"},
+		{time.Date(2022, 1, 6, 10, 0, 0, 0, time.Local), "Heilige Drei Könige"},
+		{time.Date(2021, 11, 2, 10, 0, 0, 0, time.Local), ""},
+		{time.Date(2021, 8, 15, 10, 0, 0, 0, time.Local), ""}, // outside of the lecture period
+	}
+	for _, test := range tests {
+		h, lectureFree := IsLectureFree(test.time)
+		if lectureFree != (test.expected != "") || h.Name != test.expected {
+			t.Errorf("IsLectureFree(%v): expected %q, got %q", test.time, test.expected, h.Name)
+		}
+	}
+}
+
+func TestMarkLectureFree(t *testing.T) {
+	cal := fixtureCalendar(t)
+	cal.Filter()
+	courses := cal.GroupByCourse()
+	if marked := MarkLectureFree(courses); marked != 0 {
+		t.Errorf("expected no events on lecture-free days, got %d", marked)
+	}
+	courses[0].Events = append(courses[0].Events, Event{Start: time.Date(2021, 12, 23, 10, 0, 0, 0, time.Local)},
+		Event{Start: time.Date(2021, 12, 24, 10, 0, 0, 0, time.Local)})
+	if marked := MarkLectureFree(courses); marked != 1 {
+		t.Errorf("expected one event on a lecture-free day, got %d", marked)
+	}
+	if events := courses[0].Events; events[len(events)-2].LectureFree || !events[len(events)-1].LectureFree {
+		t.Error("wrong event marked lecture-free")
+	}
+}
+
+func TestRecurrences(t *testing.T) {
+	cal := fixtureCalendar(t)
+	cal.Filter()
+	course, _ := findCourse(cal.GroupByCourse(), 950000002)
+	recurrences := course.Recurrences()
+	if len(recurrences) != 1 {
+		t.Fatalf("expected one recurrence, got %d", len(recurrences))
+	}
+	r := recurrences[0]
+	if r.Weekday != time.Monday || r.StartTime != "10:00" || r.EndTime != "12:00" || r.RoomName != "MI HS2" || len(r.Events) != 5 {
+		t.Errorf("unexpected recurrence %+v", r)
+	}
+	if len(r.Skipped) != 1 || r.Skipped[0].Name != "Allerheiligen" {
+		t.Errorf("expected allerheiligen to be skipped, got %+v", r.Skipped)
+	}
+	if len(r.Missing) != 1 || r.Missing[0].Format("2006-01-02") != "2021-11-22" {
+		t.Errorf("expected 2021-11-22 to be missing, got %v", r.Missing)
+	}
+}
@@ -0,0 +1,180 @@
+package campusonline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const icsProdID = "-//RBG-TUM//CAMPUSOnline//DE"
+
+// ICSEvent is a single event of an iCalendar (RFC 5545) file
+type ICSEvent struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Location    string
+	Description string
+	Status      string // CONFIRMED, TENTATIVE or CANCELLED
+	URL         string
+	Stamp       time.Time // time the event was last changed, defaults to now
+}
+
+// WriteICS writes the events as an iCalendar file with the given calendar name to w
+func WriteICS(w io.Writer, name string, events []ICSEvent) error {
+	bw := bufio.NewWriter(w)
+	writeICSLine(bw, "BEGIN:VCALENDAR")
+	writeICSLine(bw, "VERSION:2.0")
+	writeICSLine(bw, "PRODID:"+icsProdID)
+	writeICSLine(bw, "CALSCALE:GREGORIAN")
+	writeICSLine(bw, "METHOD:PUBLISH")
+	if name != "" {
+		writeICSLine(bw, "X-WR-CALNAME:"+escapeICS(name))
+	}
+	now := time.Now()
+	for _, event := range events {
+		stamp := event.Stamp
+		if stamp.IsZero() {
+			stamp = now
+		}
+		writeICSLine(bw, "BEGIN:VEVENT")
+		writeICSLine(bw, "UID:"+escapeICS(event.UID))
+		writeICSLine(bw, "DTSTAMP:"+stamp.UTC().Format("20060102T150405Z"))
+		writeICSLine(bw, "DTSTART:"+event.Start.UTC().Format("20060102T150405Z"))
+		writeICSLine(bw, "DTEND:"+event.End.UTC().Format("20060102T150405Z"))
+		writeICSLine(bw, "SUMMARY:"+escapeICS(event.Summary))
+		if event.Location != "" {
+			writeICSLine(bw, "LOCATION:"+escapeICS(event.Location))
+		}
+		if event.Description != "" {
+			writeICSLine(bw, "DESCRIPTION:"+escapeICS(event.Description))
+		}
+		if event.Status != "" {
+			writeICSLine(bw, "STATUS:"+event.Status)
+		}
+		if event.URL != "" {
+			writeICSLine(bw, "URL:"+event.URL)
+		}
+		writeICSLine(bw, "END:VEVENT")
+	}
+	writeICSLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// writeICSLine writes line terminated by CRLF, folded after 75 octets as required by RFC 5545
+func writeICSLine(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) { // don't split multi-byte characters
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeICS(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// ICSEvents converts the calendar's events for WriteICS. Events with unparsable times are skipped.
+func (c *ICalendar) ICSEvents() []ICSEvent {
+	var res []ICSEvent
+	for _, event := range c.Vcalendar.Events {
+		start, err := time.ParseInLocation("20060102T150405", event.Dtstart, time.Local)
+		if err != nil {
+			continue
+		}
+		end, err := time.ParseInLocation("20060102T150405", event.Dtend, time.Local)
+		if err != nil {
+			continue
+		}
+		stamp, _ := time.ParseInLocation("20060102T150405", event.Dtstamp, time.Local)
+		res = append(res, ICSEvent{
+			UID:         event.Uid,
+			Start:       start,
+			End:         end,
+			Summary:     event.Summary,
+			Location:    event.Location.Text,
+			Description: event.Comment,
+			Status:      icsStatus(event.Status),
+			URL:         event.Description.Altrep,
+			Stamp:       stamp,
+		})
+	}
+	return res
+}
+
+// CourseICSEvents converts the events of the courses for WriteICS
+func CourseICSEvents(courses []Course) []ICSEvent {
+	var res []ICSEvent
+	for _, course := range courses {
+		for _, event := range course.Events {
+			res = append(res, courseICSEvent(course, event))
+		}
+	}
+	return res
+}
+
+func courseICSEvent(course Course, event Event) ICSEvent {
+	summary := event.Title
+	if summary == "" {
+		summary = course.Title
+	}
+	uid := event.EventID
+	if uid == "" {
+		uid = fmt.Sprintf("%d-%s", course.CourseID, event.Start.UTC().Format("20060102T150405Z"))
+	}
+	return ICSEvent{
+		UID:         uid + "@tumonline",
+		Start:       event.Start,
+		End:         event.End,
+		Summary:     summary,
+		Location:    event.RoomName,
+		Description: event.Comment,
+		Status:      "CONFIRMED",
+	}
+}
+
+// icsStatus maps TUMonline's event status in German or English to an iCalendar status
+func icsStatus(status string) string {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "fix", "fixed", "confirmed":
+		return "CONFIRMED"
+	case "geplant", "planned", "tentative":
+		return "TENTATIVE"
+	case "abgesagt", "cancelled", "canceled":
+		return "CANCELLED"
+	}
+	return ""
+}
+
+// ICSEvents converts the room's bookings for WriteICS. Bookings with unparsable times are skipped.
+func (r *RDM) ICSEvents() []ICSEvent {
+	var res []ICSEvent
+	for _, booking := range r.Bookings() {
+		res = append(res, ICSEvent{
+			UID:      fmt.Sprintf("%s@room-%d", booking.EventID, booking.RoomID),
+			Start:    booking.Start,
+			End:      booking.End,
+			Summary:  booking.Title,
+			Location: booking.RoomName,
+			Status:   booking.Status,
+		})
+	}
+	return res
+}
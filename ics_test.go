@@ -0,0 +1,89 @@
+package campusonline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteICS(t *testing.T) {
+	start := time.Date(2021, 10, 19, 8, 30, 0, 0, time.UTC)
+	events := []ICSEvent{{
+		UID:      "1@tumonline",
+		Start:    start,
+		End:      start.Add(time.Minute * 90),
+		Summary:  "Einführung in die Informatik; Vorlesung, Teil 1",
+		Location: strings.Repeat("Hörsaal ", 20),
+		Status:   "CONFIRMED",
+		Stamp:    start,
+	}}
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, "Informatik", events); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Informatik\r\n",
+		"DTSTART:20211019T083000Z\r\n",
+		"DTEND:20211019T100000Z\r\n",
+		`SUMMARY:Einführung in die Informatik\; Vorlesung\, Teil 1` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q", expected)
+		}
+	}
+	for _, line := range strings.Split(out, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line not folded: %q", line)
+		}
+	}
+	if !strings.Contains(strings.ReplaceAll(out, "\r\n ", ""), "LOCATION:"+strings.Repeat("Hörsaal ", 20)) {
+		t.Error("folded line doesn't unfold to the original location")
+	}
+}
+
+func TestICSEvents(t *testing.T) {
+	cal := fixtureCalendar(t)
+	events := cal.ICSEvents()
+	if len(events) != len(cal.Vcalendar.Events) {
+		t.Fatalf("expected %d events, got %d", len(cal.Vcalendar.Events), len(events))
+	}
+	cancelled := 0
+	for _, event := range events {
+		if event.Status == "CANCELLED" {
+			cancelled++
+		}
+	}
+	if cancelled != 1 {
+		t.Errorf("expected one cancelled event, got %d", cancelled)
+	}
+
+	cal.Filter()
+	courses := cal.GroupByCourse()
+	courseEvents := CourseICSEvents(courses)
+	uids := map[string]bool{}
+	for _, event := range courseEvents {
+		if event.Summary == "" || uids[event.UID] {
+			t.Errorf("unexpected event %+v", event)
+		}
+		uids[event.UID] = true
+	}
+}
+
+func TestRoomICSEvents(t *testing.T) {
+	c, _ := newTestClient(t)
+	rdm, err := c.GetRoomSchedule(2300, semesterStart, semesterEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := rdm.ICSEvents()
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events, got %d", len(events))
+	}
+	if events[0].UID != "7000001@room-2300" || !strings.HasPrefix(events[0].Location, "Hörsaal 1") {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+}
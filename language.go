@@ -0,0 +1,58 @@
+package campusonline
+
+// Language is a language TUMonline provides texts in, by its ISO 639-1 code
+type Language string
+
+const (
+	German  Language = "de"
+	English Language = "en"
+)
+
+// LocalizedText is a text in several languages
+type LocalizedText map[Language]string
+
+// In returns the text in the language, falling back to German and then to any other language
+func (t LocalizedText) In(lang Language) string {
+	if text := t[lang]; text != "" {
+		return text
+	}
+	if text := t[German]; text != "" {
+		return text
+	}
+	for _, text := range t {
+		if text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// WithLanguages sets the languages LoadCourseContacts exports courses in to fill their Titles and Descriptions, e.g.
+// WithLanguages(German, English). Each language costs one request per course. The contacts are taken from the export in
+// the first language. Defaults to German.
+func WithLanguages(langs ...Language) Option {
+	return func(c *CampusOnline) {
+		if len(langs) != 0 {
+			c.languages = langs
+		}
+	}
+}
+
+// ExportCourseIn returns the course export with texts in the language. TUMonline exports courses without translation
+// in German.
+func (c *CampusOnline) ExportCourseIn(id int, lang Language) (CDM, error) {
+	return c.exportCourse(id, lang)
+}
+
+// exportCourse requests German exports without language parameter, as TUMonline exports German by default
+func (c *CampusOnline) exportCourse(id int, lang Language) (CDM, error) {
+	if lang == German || lang == "" {
+		return c.exportCourseByID(id)
+	}
+	var result CDM
+	err := c.getXML(wsURL(courseExportLangDN, id, string(lang)), &result)
+	if err != nil {
+		return CDM{}, err
+	}
+	return result, nil
+}
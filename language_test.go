@@ -0,0 +1,73 @@
+package campusonline
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocalizedText(t *testing.T) {
+	text := LocalizedText{German: "Einführung", English: "Introduction"}
+	if text.In(English) != "Introduction" || text.In(German) != "Einführung" || text.In("fr") != "Einführung" {
+		t.Errorf("unexpected texts %q, %q, %q", text.In(English), text.In(German), text.In("fr"))
+	}
+	if (LocalizedText{English: "Introduction"}).In(German) != "Introduction" || LocalizedText(nil).In(German) != "" {
+		t.Errorf("expected fallback to any language")
+	}
+}
+
+func TestLoadCourseContactsLanguages(t *testing.T) {
+	c, server := newTestClient(t, WithLanguages(German, English))
+	courses, err := c.LoadCourseContacts([]Course{{CourseID: 950000001}, {CourseID: 950000002, Title: "DS"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := courses[0]
+	if info.Title != "Einführung in die Informatik" || info.Titles[English] != "Introduction to Informatics" ||
+		info.Descriptions.In(English) != "Description of Introduction to Informatics" {
+		t.Errorf("unexpected texts %q %v %v", info.Title, info.Titles, info.Descriptions)
+	}
+	if len(info.Contacts) != 2 || info.Contacts[1].Role != "Leiter/in, Prüfer/in" {
+		t.Errorf("expected the contacts of the German export once, got %+v", info.Contacts)
+	}
+	// untranslated courses are exported in German
+	if ds := courses[1]; ds.Title != "DS" || ds.Titles[English] != ds.Titles[German] || ds.Titles[German] == "" {
+		t.Errorf("unexpected titles %q %v", ds.Title, ds.Titles)
+	}
+	english := 0
+	for _, request := range server.Requests() {
+		if strings.HasSuffix(request, "&language=en") {
+			english++
+		}
+	}
+	if english != 2 {
+		t.Errorf("expected 2 requests in English, got %v", server.Requests())
+	}
+}
+
+func TestEnglishContacts(t *testing.T) {
+	c, _ := newTestClient(t, WithLanguages(English))
+	courses, err := c.LoadCourseContacts([]Course{{CourseID: 950000001}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seidl := courses[0].Contacts[1]
+	if courses[0].Title != "Introduction to Informatics" || seidl.Role != "Course leader, Examiner" ||
+		!seidl.HasRole(RoleLecturer) || !seidl.MainContact {
+		t.Errorf("unexpected contact %+v in %q", seidl, courses[0].Title)
+	}
+	if seidl.RoleTitle(German) != "Leiter/in, Prüfer/in" || seidl.RoleTitle(English) != "Lecturer, Examiner" {
+		t.Errorf("unexpected role titles %q, %q", seidl.RoleTitle(German), seidl.RoleTitle(English))
+	}
+	if unknown := (ContactPerson{Role: "Gast", Roles: []Role{RoleUnknown}}); unknown.RoleTitle(English) != "Gast" {
+		t.Errorf("expected unknown roles as exported, got %q", unknown.RoleTitle(English))
+	}
+}
+
+func TestSemesterNameIn(t *testing.T) {
+	if name := (Semester{2024, Winter}).NameIn(English); name != "Winter semester 2024/25" {
+		t.Errorf("unexpected name %q", name)
+	}
+	if name := (Semester{2024, Summer}).NameIn(German); name != "Sommersemester 2024" {
+		t.Errorf("unexpected name %q", name)
+	}
+}
@@ -0,0 +1,254 @@
+package campusonline
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Lecturer is a person listed in the lecturers of a search result
+type Lecturer struct {
+	Title     string `json:"title"`      // academic titles, e.g. "Prof. Dr."
+	FirstName string `json:"first_name"` // only the initials if TUMonline lists the person as e.g. "Seidl H"
+	LastName  string `json:"last_name"`
+	Role      Role   `json:"role"`
+}
+
+// titles are the parts of academic titles and degrees preceding names, e.g. "Dr.-Ing." or "Dipl.-Inf.", without
+// dots and in lower case
+var titles = map[string]bool{
+	"prof": true, "dr": true, "pd": true, "habil": true, "apl": true, "hon": true, "em": true, "priv": true,
+	"doz": true, "dipl": true, "ing": true, "inf": true, "rer": true, "nat": true, "med": true, "phil": true,
+	"techn": true, "jur": true, "oec": true, "mag": true, "univ": true, "msc": true, "bsc": true, "mba": true,
+}
+
+// particles are the lower case words preceding last names like "van der Berg" or "von Neumann"
+var particles = map[string]bool{
+	"van": true, "von": true, "vom": true, "de": true, "der": true, "den": true, "di": true, "da": true, "del": true,
+	"della": true, "du": true, "dos": true, "zu": true, "zum": true, "zur": true, "ten": true, "ter": true,
+}
+
+// lecturerRoleCodes are the abbreviated roles TUMonline appends in brackets, e.g. "Seidl H [L]". Other roles are
+// spelled out, e.g. "(Leiter/in)".
+var lecturerRoleCodes = map[string]Role{
+	"L": RoleLecturer,
+}
+
+// Lecturers parses the free text list of lecturers and contributors, e.g. "Seidl H [L], Petter M" or
+// "Prof. Dr. Javier Esparza (Leiter/in); Dr. Michael Luttenberger". It returns nil if TUMonline sent no lecturers.
+func (r RowsetRow) Lecturers() []Lecturer {
+	if r.VortragendeMitwirkende.Isnull == "true" {
+		return nil
+	}
+	return ParseLecturers(r.VortragendeMitwirkende.Text)
+}
+
+// ParseLecturers parses a list of lecturers separated by semicolons, or by commas if there are no semicolons. Names
+// may be given as "Last, First", e.g. "Esparza, Javier; Seidl, Helmut". Comma separated lists of single names, e.g.
+// "Prof. Esparza, Javier, Seidl, H.", are only read in pairs of last and first name if a pair is recognisable as such
+// by a title, a particle like "van der" or abbreviated first name. Without such a hint, e.g. "Esparza, Seidl", each
+// name is a lecturer.
+func ParseLecturers(text string) []Lecturer {
+	sep := ","
+	if strings.Contains(text, ";") {
+		sep = ";"
+	}
+	entries := strings.Split(text, sep)
+	if sep == "," && lastFirstPairs(entries) {
+		var pairs []string
+		for i := 0; i < len(entries); i += 2 {
+			pairs = append(pairs, entries[i]+","+entries[i+1])
+		}
+		entries = pairs
+	}
+	var res []Lecturer
+	for _, entry := range entries {
+		if l, ok := parseLecturer(entry); ok {
+			res = append(res, l)
+		}
+	}
+	return res
+}
+
+// lastFirstPairs reports whether the comma separated entries are pairs of last and first names. That is an even
+// number of entries with a single name each, where no first name has a title and at least one pair is recognisable
+// by a title or particle of the last name or an abbreviated first name.
+func lastFirstPairs(entries []string) bool {
+	if len(entries) == 0 || len(entries)%2 != 0 {
+		return false
+	}
+	recognisable := false
+	for i := 0; i < len(entries); i += 2 {
+		last, ok := parseLecturer(entries[i])
+		if !ok || last.FirstName != "" {
+			return false
+		}
+		fields := strings.Fields(entries[i+1])
+		first, ok := parseLecturer(entries[i+1])
+		if !ok || first.FirstName != "" || isTitle(fields[0]) { // a title starts the next lecturer
+			return false
+		}
+		if last.Title != "" || hasParticle(strings.Fields(last.LastName)) || isInitials(first.LastName) ||
+			strings.HasSuffix(first.LastName, ".") {
+			recognisable = true
+		}
+	}
+	return recognisable
+}
+
+func parseLecturer(entry string) (Lecturer, bool) {
+	var l Lecturer
+	entry = strings.Trim(entry, " ,;")
+	for _, brackets := range []string{"[]", "()"} {
+		open := strings.IndexByte(entry, brackets[0])
+		end := strings.LastIndexByte(entry, brackets[1])
+		if open < 0 || end < open {
+			continue
+		}
+		role := strings.TrimSpace(entry[open+1 : end])
+		if r, found := lecturerRoleCodes[strings.ToUpper(role)]; found {
+			l.Role = r
+		} else {
+			l.Role = ParseRole(role)
+		}
+		entry = strings.TrimSpace(entry[:open] + " " + entry[end+1:])
+	}
+	if comma := strings.IndexByte(entry, ','); comma >= 0 { // "Esparza, Javier"
+		title, last := splitTitles(strings.Fields(entry[:comma]))
+		firstTitle, first := splitTitles(strings.Fields(entry[comma+1:]))
+		if len(last) == 0 {
+			return Lecturer{}, false
+		}
+		l.Title = strings.Join(append(title, firstTitle...), " ")
+		l.LastName = strings.Join(last, " ")
+		l.FirstName = strings.Join(first, " ")
+		return l, true
+	}
+	title, names := splitTitles(strings.Fields(entry))
+	if len(names) == 0 {
+		return Lecturer{}, false
+	}
+	l.Title = strings.Join(title, " ")
+	last := names[len(names)-1]
+	switch {
+	case len(names) == 1:
+		l.LastName = last
+	case isInitials(last): // "Seidl H"
+		l.LastName = strings.Join(names[:len(names)-1], " ")
+		l.FirstName = last
+	default: // "Javier Esparza" or "Jan van der Berg"
+		start := len(names) - 1
+		for i := len(names) - 2; i >= 0 && particles[strings.ToLower(names[i])]; i-- {
+			start = i
+		}
+		l.FirstName = strings.Join(names[:start], " ")
+		l.LastName = strings.Join(names[start:], " ")
+	}
+	return l, true
+}
+
+// splitTitles splits the leading and trailing titles off the names, keeping at least one name. Trailing degrees like
+// in "Anna Schmidt M.Sc." are added to the title.
+func splitTitles(names []string) ([]string, []string) {
+	var title []string
+	for len(names) > 1 && isTitle(names[0]) {
+		title = append(title, names[0])
+		names = names[1:]
+	}
+	end := len(names)
+	for end > 1 && isTitle(names[end-1]) {
+		end--
+	}
+	return append(title, names[end:]...), names[:end]
+}
+
+// hasParticle reports whether the names start with a particle followed by a name, like "van der Berg"
+func hasParticle(names []string) bool {
+	return len(names) > 1 && particles[strings.ToLower(names[0])]
+}
+
+// isTitle reports whether s is a title or degree like "Prof.", "PD", "Dr.-Ing." or "M.Sc.". Abbreviated first names
+// like "Hans-J." aren't titles.
+func isTitle(s string) bool {
+	s = strings.ToLower(s)
+	if titles[strings.ReplaceAll(s, ".", "")] {
+		return true
+	}
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '-' })
+	for _, part := range parts {
+		if !titles[part] {
+			return false
+		}
+	}
+	return len(parts) != 0
+}
+
+// isInitials reports whether s is an abbreviated first name like "H", "H." or "HJ"
+func isInitials(s string) bool {
+	letters := 0
+	for _, r := range s {
+		switch {
+		case r == '.' || r == '-':
+		case unicode.IsUpper(r):
+			letters++
+		default:
+			return false
+		}
+	}
+	return letters > 0 && letters <= 3
+}
+
+// initials returns the first letters of the parts of a first name, e.g. "HJ" for "Hans-Joachim" or "H.J."
+func initials(name string) string {
+	if isInitials(name) {
+		return strings.NewReplacer(".", "", "-", "", " ", "").Replace(name)
+	}
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == ' ' || r == '-' || r == '.' }) {
+		sb.WriteRune([]rune(part)[0])
+	}
+	return strings.ToUpper(sb.String())
+}
+
+// matchesName reports whether the lecturer is the person with the given names. Abbreviated first names like "HJ" or
+// "Hans-J." match if the initials agree.
+func (l Lecturer) matchesName(firstName string, lastName string) bool {
+	if !strings.EqualFold(l.LastName, strings.TrimSpace(lastName)) {
+		return false
+	}
+	if l.FirstName == "" {
+		return true
+	}
+	if isInitials(l.FirstName) || strings.Contains(l.FirstName, ".") {
+		return strings.HasPrefix(initials(firstName), initials(l.FirstName))
+	}
+	return strings.EqualFold(l.FirstName, strings.TrimSpace(firstName))
+}
+
+// MatchContact returns the contact of a course export that is the lecturer
+func (l Lecturer) MatchContact(contacts []ContactPerson) (ContactPerson, bool) {
+	for _, contact := range contacts {
+		if l.matchesName(contact.FirstName, contact.LastName) {
+			return contact, true
+		}
+	}
+	return ContactPerson{}, false
+}
+
+// MatchLecturer returns the person of the directory that is the lecturer. Lecturers matching several people aren't
+// resolved.
+func (p *People) MatchLecturer(l Lecturer) (Person, bool) {
+	var match *Person
+	for _, person := range p.byID {
+		if !l.matchesName(person.FirstName, person.LastName) {
+			continue
+		}
+		if match != nil {
+			return Person{}, false
+		}
+		match = person
+	}
+	if match == nil {
+		return Person{}, false
+	}
+	return match.copy(), true
+}
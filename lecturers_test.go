@@ -0,0 +1,107 @@
+package campusonline
+
+import (
+	"testing"
+)
+
+func TestParseLecturers(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []Lecturer
+	}{
+		{"Seidl H [L], Petter M", []Lecturer{
+			{FirstName: "H", LastName: "Seidl", Role: RoleLecturer},
+			{FirstName: "M", LastName: "Petter"},
+		}},
+		{"Prof. Dr. Javier Esparza (Leiter/in); Dr. Michael Luttenberger", []Lecturer{
+			{Title: "Prof. Dr.", FirstName: "Javier", LastName: "Esparza", Role: RoleLecturer},
+			{Title: "Dr.", FirstName: "Michael", LastName: "Luttenberger"},
+		}},
+		{"PD Dr.-Ing. habil. Müller-Lüdenscheidt HJ (Tutor/in)", []Lecturer{
+			{Title: "PD Dr.-Ing. habil.", FirstName: "HJ", LastName: "Müller-Lüdenscheidt", Role: RoleTutor},
+		}},
+		{"Dr. Seidl", []Lecturer{{Title: "Dr.", LastName: "Seidl"}}},
+		{"Prof. Dr. Hans-J. Bungartz", []Lecturer{
+			{Title: "Prof. Dr.", FirstName: "Hans-J.", LastName: "Bungartz"},
+		}},
+		{"M.Sc. Anna Schmidt, Dr. rer. nat. Peter Lang", []Lecturer{
+			{Title: "M.Sc.", FirstName: "Anna", LastName: "Schmidt"},
+			{Title: "Dr. rer. nat.", FirstName: "Peter", LastName: "Lang"},
+		}},
+		{"Esparza, J.", []Lecturer{{FirstName: "J.", LastName: "Esparza"}}},
+		{"van der Berg, Jan", []Lecturer{{FirstName: "Jan", LastName: "van der Berg"}}},
+		{"Jan van der Berg; Dr. von Neumann J", []Lecturer{
+			{FirstName: "Jan", LastName: "van der Berg"},
+			{Title: "Dr.", FirstName: "J", LastName: "von Neumann"},
+		}},
+		{"Anna Schmidt M.Sc.", []Lecturer{{Title: "M.Sc.", FirstName: "Anna", LastName: "Schmidt"}}},
+		{"Dr. Schmidt, Anna M.Sc., Berg, Jan", []Lecturer{
+			{Title: "Dr. M.Sc.", FirstName: "Anna", LastName: "Schmidt"},
+			{FirstName: "Jan", LastName: "Berg"},
+		}},
+		{"Esparza, Seidl", []Lecturer{{LastName: "Esparza"}, {LastName: "Seidl"}}},
+		{"Esparza, Dr. Seidl", []Lecturer{{LastName: "Esparza"}, {Title: "Dr.", LastName: "Seidl"}}},
+		{"Prof. Dr. Esparza, Javier (Leiter/in), Seidl, Helmut", []Lecturer{
+			{Title: "Prof. Dr.", FirstName: "Javier", LastName: "Esparza", Role: RoleLecturer},
+			{FirstName: "Helmut", LastName: "Seidl"},
+		}},
+		{"Esparza, Javier; Dr. Luttenberger, M", []Lecturer{
+			{FirstName: "Javier", LastName: "Esparza"},
+			{Title: "Dr.", FirstName: "M", LastName: "Luttenberger"},
+		}},
+		{" ; , ", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		lecturers := ParseLecturers(test.text)
+		if len(lecturers) != len(test.expected) {
+			t.Errorf("%q: expected %+v, got %+v", test.text, test.expected, lecturers)
+			continue
+		}
+		for i := range lecturers {
+			if lecturers[i] != test.expected[i] {
+				t.Errorf("%q: expected %+v, got %+v", test.text, test.expected[i], lecturers[i])
+			}
+		}
+	}
+}
+
+func TestSearchResultLecturers(t *testing.T) {
+	c, _ := newTestClient(t)
+	rows, err := c.SearchCourses("Informatik", Semester{2021, Winter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lecturers := rows.Row[1].Lecturers(); lecturers != nil {
+		t.Errorf("expected no lecturers for null value, got %+v", lecturers)
+	}
+	lecturers := rows.Row[0].Lecturers()
+	if len(lecturers) != 2 {
+		t.Fatalf("expected 2 lecturers, got %+v", lecturers)
+	}
+
+	courses, err := c.LoadCourseContacts([]Course{{CourseID: 950000001}, {CourseID: 950000002}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contact, found := lecturers[0].MatchContact(courses[0].Contacts)
+	if !found || contact.PersonID != "A1B2C3D4E5F6" {
+		t.Errorf("expected Seidl, got %+v", contact)
+	}
+	if _, found := (Lecturer{FirstName: "K", LastName: "Seidl"}).MatchContact(courses[0].Contacts); found {
+		t.Errorf("expected other initials not to match")
+	}
+	if !(Lecturer{FirstName: "Hans-J.", LastName: "Bungartz"}).matchesName("Hans-Joachim", "Bungartz") {
+		t.Errorf("expected abbreviated first name to match")
+	}
+
+	people := NewPeople(courses)
+	for _, l := range rows.Row[2].Lecturers() {
+		if person, found := people.MatchLecturer(l); !found || person.LastName != l.LastName {
+			t.Errorf("expected %+v in the directory, got %+v", l, person)
+		}
+	}
+	if _, found := people.MatchLecturer(Lecturer{LastName: "Unbekannt"}); found {
+		t.Errorf("expected unknown lecturer not to match")
+	}
+}
@@ -0,0 +1,133 @@
+package campusonline
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Logger receives the client's log output. Arguments are alternating keys and values like in log/slog, so a
+// *slog.Logger can be used directly.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// WithLogger makes the client log to l. Tokens are redacted from all messages and arguments before they reach l.
+func WithLogger(l Logger) Option {
+	return func(c *CampusOnline) {
+		c.logger = l
+	}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// redactingLogger redacts tokens from everything it passes on to the wrapped logger
+type redactingLogger struct {
+	l      Logger
+	redact func(string) string
+}
+
+func (r redactingLogger) Debug(msg string, args ...interface{}) {
+	r.l.Debug(r.redact(msg), r.redactArgs(args)...)
+}
+
+func (r redactingLogger) Info(msg string, args ...interface{}) {
+	r.l.Info(r.redact(msg), r.redactArgs(args)...)
+}
+
+func (r redactingLogger) Warn(msg string, args ...interface{}) {
+	r.l.Warn(r.redact(msg), r.redactArgs(args)...)
+}
+
+func (r redactingLogger) Error(msg string, args ...interface{}) {
+	r.l.Error(r.redact(msg), r.redactArgs(args)...)
+}
+
+func (r redactingLogger) redactArgs(args []interface{}) []interface{} {
+	res := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			res[i] = r.redact(v)
+		case error:
+			res[i] = r.redact(v.Error())
+		case fmt.Stringer:
+			res[i] = r.redact(v.String())
+		default:
+			res[i] = arg
+		}
+	}
+	return res
+}
+
+const redacted = "REDACTED"
+
+var tokenParamRegex = regexp.MustCompile(`(?i)\b(p?token)=[^&\s"'<>]*`)
+
+// Redact replaces the values of token and pToken parameters in s, e.g. in urls, with REDACTED
+func Redact(s string) string {
+	return tokenParamRegex.ReplaceAllString(s, "${1}="+redacted)
+}
+
+// redact removes the client's tokens from s, wherever they appear
+func (c *CampusOnline) redact(s string) string {
+	s = Redact(s)
+	c.knownTokens.Range(func(token, _ interface{}) bool {
+		s = strings.ReplaceAll(s, token.(string), redacted)
+		return true
+	})
+	return s
+}
+
+// redactError returns err with the client's tokens removed from its message and the messages of all errors it wraps.
+// url errors keep their type so they can still be inspected with errors.As.
+func (c *CampusOnline) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if urlErr, ok := err.(*url.Error); ok {
+		return &url.Error{Op: urlErr.Op, URL: c.redact(urlErr.URL), Err: c.redactError(urlErr.Err)}
+	}
+	if msg := c.redact(err.Error()); msg != err.Error() {
+		return &redactedError{msg: msg, err: err, next: c.redactError(errors.Unwrap(err))}
+	}
+	return err
+}
+
+// redactedError hides an error whose message contains tokens. Only the redacted chain of wrapped errors is exposed.
+type redactedError struct {
+	msg  string
+	err  error // the original error, never returned
+	next error // the redacted error wrapped by err
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.next
+}
+
+// Is matches the original error, e.g. against ErrInvalidToken, without exposing it
+func (e *redactedError) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
+
+// As finds a StatusError of the original error, which never contains tokens
+func (e *redactedError) As(target interface{}) bool {
+	if statusErr, ok := target.(**StatusError); ok {
+		return errors.As(e.err, statusErr)
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package campusonline
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+// recordingLogger keeps every log line formatted as a single string
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) log(level string, msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprint(append([]interface{}{level, msg}, args...)...))
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg, args...) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.log("INFO", msg, args...) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.log("WARN", msg, args...) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.log("ERROR", msg, args...) }
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{"https://campus.tum.de/ws/xcal?token=secret&orgUnitID=1", "https://campus.tum.de/ws/xcal?token=REDACTED&orgUnitID=1"},
+		{"wbservicesbasic.orgBaum?pToken=secret", "wbservicesbasic.orgBaum?pToken=REDACTED"},
+		{`Get "https://x/?a=1&TOKEN=secret": timeout`, `Get "https://x/?a=1&TOKEN=REDACTED": timeout`},
+		{"no tokens here", "no tokens here"},
+	}
+	for _, test := range tests {
+		if got := Redact(test.in); got != test.expected {
+			t.Errorf("Redact(%q): expected %q, got %q", test.in, test.expected, got)
+		}
+	}
+}
+
+func TestLoggingRedactsTokens(t *testing.T) {
+	logger := &recordingLogger{}
+	c, server := newTestClient(t, WithLogger(logger))
+	if _, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId); err != nil {
+		t.Fatal(err)
+	}
+	server.Fail(campusonlinetest.EndpointOrgTree, 500)
+	if _, err := c.GetOrganisations(); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(logger.lines) < 3 {
+		t.Fatalf("expected requests to be logged, got %v", logger.lines)
+	}
+	for _, line := range logger.lines {
+		if strings.Contains(line, campusonlinetest.Token) || strings.Contains(line, campusonlinetest.BasicToken) {
+			t.Errorf("token leaked into log line %q", line)
+		}
+	}
+}
+
+func TestErrorsRedactTokens(t *testing.T) {
+	logger := &recordingLogger{}
+	c, server := newTestClient(t, WithLogger(logger))
+	server.Close()
+	_, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId)
+	if err == nil {
+		t.Fatal("expected error from closed server")
+	}
+	if strings.Contains(err.Error(), campusonlinetest.Token) || !strings.Contains(err.Error(), "token=REDACTED") {
+		t.Errorf("token not redacted from error %q", err)
+	}
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Errorf("expected *url.Error, got %T", err)
+	}
+	for _, line := range logger.lines {
+		if strings.Contains(line, campusonlinetest.Token) {
+			t.Errorf("token leaked into log line %q", line)
+		}
+	}
+}
+
+func TestRedactedErrorChain(t *testing.T) {
+	c, _ := newTestClient(t)
+	if _, err := c.ExportCourse(950000001); err != nil {
+		t.Fatal(err)
+	}
+	inner := fmt.Errorf("token %s rejected: %w", campusonlinetest.Token, &StatusError{StatusCode: http.StatusUnauthorized})
+	err := c.redactError(fmt.Errorf("get ?token=%s: %w", campusonlinetest.Token, inner))
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if msg := fmt.Sprintf("%v %+v", e, e); strings.Contains(msg, campusonlinetest.Token) {
+			t.Errorf("token leaked from wrapped error %q", msg)
+		}
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized || !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected the status error to stay reachable, got %v", err)
+	}
+}
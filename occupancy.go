@@ -0,0 +1,222 @@
+package campusonline
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval is the time from Start to End
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Duration returns the length of the interval
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// DailyHours restricts occupancy analysis to a time of day, e.g. DailyHours{From: 8 * time.Hour, Until: 20 * time.Hour}.
+// The zero value covers the whole day.
+type DailyHours struct {
+	From  time.Duration // since midnight
+	Until time.Duration // since midnight, 0 means the end of the day
+}
+
+// intervals returns the parts of from..until within the daily hours
+func (h DailyHours) intervals(from time.Time, until time.Time) []Interval {
+	var res []Interval
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for ; day.Before(until); day = day.AddDate(0, 0, 1) {
+		open := timeOfDay(day, h.From)
+		closed := day.AddDate(0, 0, 1)
+		if h.Until != 0 {
+			closed = timeOfDay(day, h.Until)
+		}
+		if open.Before(from) {
+			open = from
+		}
+		if closed.After(until) {
+			closed = until
+		}
+		if open.Before(closed) {
+			res = append(res, Interval{Start: open, End: closed})
+		}
+	}
+	return res
+}
+
+// timeOfDay returns the wall clock time d after midnight of day, so daily hours don't shift on days with daylight
+// saving time changes
+func timeOfDay(day time.Time, d time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(d/time.Hour), int(d%time.Hour/time.Minute),
+		int(d%time.Minute/time.Second), 0, day.Location())
+}
+
+// Occupancy is the usage of a room within a time range
+type Occupancy struct {
+	RoomID   int        `json:"room_id"` // 0 for rooms only known by name
+	RoomName string     `json:"room_name"`
+	Open     []Interval `json:"open"` // the analysed time, the range restricted to the daily hours
+	Busy     []Interval `json:"busy"` // the merged bookings within Open
+	Free     []Interval `json:"free"`
+}
+
+// NewOccupancy computes the occupancy of a room with the given bookings within from..until, restricted to the hours
+func NewOccupancy(roomID int, roomName string, from time.Time, until time.Time, hours DailyHours, bookings []Interval) Occupancy {
+	res := Occupancy{RoomID: roomID, RoomName: roomName, Open: hours.intervals(from, until)}
+	merged := mergeIntervals(bookings)
+	for _, open := range res.Open {
+		start := open.Start
+		for _, busy := range merged {
+			if !busy.End.After(open.Start) || !busy.Start.Before(open.End) {
+				continue
+			}
+			clipped := Interval{Start: latest(busy.Start, open.Start), End: earliest(busy.End, open.End)}
+			if start.Before(clipped.Start) {
+				res.Free = append(res.Free, Interval{Start: start, End: clipped.Start})
+			}
+			res.Busy = append(res.Busy, clipped)
+			start = clipped.End
+		}
+		if start.Before(open.End) {
+			res.Free = append(res.Free, Interval{Start: start, End: open.End})
+		}
+	}
+	return res
+}
+
+// mergeIntervals returns the intervals sorted by start with overlapping and adjacent ones joined
+func mergeIntervals(intervals []Interval) []Interval {
+	sorted := append([]Interval(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+	var res []Interval
+	for _, i := range sorted {
+		if !i.Start.Before(i.End) {
+			continue
+		}
+		if n := len(res); n > 0 && !i.Start.After(res[n-1].End) {
+			res[n-1].End = latest(res[n-1].End, i.End)
+			continue
+		}
+		res = append(res, i)
+	}
+	return res
+}
+
+func earliest(a time.Time, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func latest(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func totalDuration(intervals []Interval) time.Duration {
+	var res time.Duration
+	for _, i := range intervals {
+		res += i.Duration()
+	}
+	return res
+}
+
+// Utilisation returns the percentage of the open time the room is booked
+func (o Occupancy) Utilisation() float64 {
+	open := totalDuration(o.Open)
+	if open == 0 {
+		return 0
+	}
+	return float64(totalDuration(o.Busy)) / float64(open) * 100
+}
+
+// FreeSlots returns the free intervals of at least the duration
+func (o Occupancy) FreeSlots(duration time.Duration) []Interval {
+	var res []Interval
+	for _, free := range o.Free {
+		if free.Duration() >= duration {
+			res = append(res, free)
+		}
+	}
+	return res
+}
+
+// FreeSlot is a free interval of a room
+type FreeSlot struct {
+	RoomID   int    `json:"room_id"`
+	RoomName string `json:"room_name"`
+	Interval
+}
+
+// FindFreeSlots returns the free intervals of at least the duration in any of the rooms, ordered by start and then by
+// the order of the rooms
+func FindFreeSlots(rooms []Occupancy, duration time.Duration) []FreeSlot {
+	var res []FreeSlot
+	for _, room := range rooms {
+		for _, free := range room.FreeSlots(duration) {
+			res = append(res, FreeSlot{RoomID: room.RoomID, RoomName: room.RoomName, Interval: free})
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Start.Before(res[j].Start) })
+	return res
+}
+
+// Occupancy computes the occupancy of the room within from..until. Cancelled bookings don't occupy the room.
+func (r *RDM) Occupancy(from time.Time, until time.Time, hours DailyHours) Occupancy {
+	roomID, roomName := r.room()
+	var bookings []Interval
+	for _, booking := range FilterBookings(r.Bookings(), BookingFilter{ExcludeCancelled: true}) {
+		bookings = append(bookings, Interval{Start: booking.Start, End: booking.End})
+	}
+	return NewOccupancy(roomID, roomName, from, until, hours, bookings)
+}
+
+// RoomOccupancy computes the occupancy of the rooms of the calendar's events within from..until, ordered by room name.
+// Rooms are identified by the events' locations, events without location and cancelled events are ignored.
+func (c *ICalendar) RoomOccupancy(from time.Time, until time.Time, hours DailyHours) []Occupancy {
+	bookings := map[string][]Interval{}
+	var rooms []string
+	for _, event := range c.ICSEvents() {
+		if event.Location == "" || event.Status == "CANCELLED" {
+			continue
+		}
+		if _, found := bookings[event.Location]; !found {
+			rooms = append(rooms, event.Location)
+		}
+		bookings[event.Location] = append(bookings[event.Location], Interval{Start: event.Start, End: event.End})
+	}
+	sort.Strings(rooms)
+	res := make([]Occupancy, 0, len(rooms))
+	for _, room := range rooms {
+		res = append(res, NewOccupancy(0, room, from, until, hours, bookings[room]))
+	}
+	return res
+}
+
+// GetRoomOccupancy fetches the schedules of the rooms in parallel and computes their occupancy within from..until, in
+// the order of roomIDs
+func (c *CampusOnline) GetRoomOccupancy(roomIDs []int, from time.Time, until time.Time, hours DailyHours) ([]Occupancy, error) {
+	res := make([]Occupancy, len(roomIDs))
+	errs := c.parallel(len(roomIDs), func(i int) error {
+		rdm, err := c.GetRoomSchedule(roomIDs[i], from, until)
+		if err != nil {
+			return err
+		}
+		res[i] = rdm.Occupancy(from, until, hours)
+		if res[i].RoomID == 0 {
+			res[i].RoomID = roomIDs[i]
+		}
+		return nil
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
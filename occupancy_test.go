@@ -0,0 +1,132 @@
+package campusonline
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func at(day int, hour int, min int) time.Time {
+	return time.Date(2021, 10, day, hour, min, 0, 0, time.Local)
+}
+
+func TestNewOccupancy(t *testing.T) {
+	bookings := []Interval{
+		{Start: at(18, 10, 0), End: at(18, 12, 0)},
+		{Start: at(18, 7, 0), End: at(18, 9, 0)},
+		{Start: at(18, 11, 0), End: at(18, 13, 0)}, // overlaps the first
+		{Start: at(18, 13, 0), End: at(18, 14, 0)}, // adjacent to the third
+		{Start: at(18, 19, 0), End: at(19, 9, 0)},  // spans the night
+	}
+	o := NewOccupancy(1, "room", at(18, 0, 0), at(20, 0, 0), DailyHours{From: 8 * time.Hour, Until: 20 * time.Hour}, bookings)
+	expectedBusy := []Interval{
+		{Start: at(18, 8, 0), End: at(18, 9, 0)},
+		{Start: at(18, 10, 0), End: at(18, 14, 0)},
+		{Start: at(18, 19, 0), End: at(18, 20, 0)},
+		{Start: at(19, 8, 0), End: at(19, 9, 0)},
+	}
+	expectedFree := []Interval{
+		{Start: at(18, 9, 0), End: at(18, 10, 0)},
+		{Start: at(18, 14, 0), End: at(18, 19, 0)},
+		{Start: at(19, 9, 0), End: at(19, 20, 0)},
+	}
+	if !equalIntervals(o.Busy, expectedBusy) {
+		t.Errorf("expected busy %v, got %v", expectedBusy, o.Busy)
+	}
+	if !equalIntervals(o.Free, expectedFree) {
+		t.Errorf("expected free %v, got %v", expectedFree, o.Free)
+	}
+	if u := o.Utilisation(); math.Abs(u-7.0/24*100) > 0.001 {
+		t.Errorf("expected utilisation of 29.2%%, got %f", u)
+	}
+	if slots := o.FreeSlots(2 * time.Hour); len(slots) != 2 {
+		t.Errorf("expected 2 slots of 2 hours, got %v", slots)
+	}
+
+	whole := NewOccupancy(1, "room", at(18, 12, 0), at(19, 0, 0), DailyHours{}, nil)
+	if len(whole.Free) != 1 || whole.Free[0].Duration() != 12*time.Hour || whole.Utilisation() != 0 {
+		t.Errorf("expected the rest of the day to be free, got %v", whole.Free)
+	}
+}
+
+func TestDailyHoursDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	hours := DailyHours{From: 8 * time.Hour, Until: 20 * time.Hour}
+	// the clocks change on these days
+	for _, date := range []time.Time{time.Date(2024, 3, 31, 0, 0, 0, 0, berlin), time.Date(2024, 10, 27, 0, 0, 0, 0, berlin)} {
+		o := NewOccupancy(1, "room", date, date.AddDate(0, 0, 1), hours, nil)
+		expected := []Interval{{
+			Start: time.Date(date.Year(), date.Month(), date.Day(), 8, 0, 0, 0, berlin),
+			End:   time.Date(date.Year(), date.Month(), date.Day(), 20, 0, 0, 0, berlin),
+		}}
+		if !equalIntervals(o.Open, expected) {
+			t.Errorf("%s: expected open %v, got %v", date.Format("2006-01-02"), expected, o.Open)
+		}
+	}
+}
+
+func equalIntervals(a []Interval, b []Interval) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Start.Equal(b[i].Start) || !a[i].End.Equal(b[i].End) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRoomOccupancy(t *testing.T) {
+	c, _ := newTestClient(t)
+	hours := DailyHours{From: 8 * time.Hour, Until: 20 * time.Hour}
+	rooms, err := c.GetRoomOccupancy([]int{2300}, at(18, 0, 0), at(26, 0, 0), hours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := rooms[0]
+	if o.RoomID != 2300 || o.RoomName == "" || len(o.Open) != 8 {
+		t.Fatalf("unexpected occupancy %+v", o)
+	}
+	// the cancelled booking on the 22nd is free and the early booking on the 25th starts at opening time
+	expectedBusy := []Interval{
+		{Start: at(19, 8, 30), End: at(19, 10, 0)},
+		{Start: at(20, 10, 0), End: at(20, 12, 0)},
+		{Start: at(22, 14, 0), End: at(22, 16, 0)},
+		{Start: at(25, 8, 0), End: at(25, 9, 0)},
+	}
+	if !equalIntervals(o.Busy, expectedBusy) {
+		t.Errorf("expected busy %v, got %v", expectedBusy, o.Busy)
+	}
+	if u := o.Utilisation(); math.Abs(u-6.5/96*100) > 0.001 {
+		t.Errorf("unexpected utilisation %f", u)
+	}
+}
+
+func TestFindFreeSlots(t *testing.T) {
+	cal := fixtureCalendar(t)
+	rooms := cal.RoomOccupancy(at(18, 0, 0), at(19, 0, 0), DailyHours{From: 8 * time.Hour, Until: 20 * time.Hour})
+	if len(rooms) < 2 {
+		t.Fatalf("expected several rooms, got %d", len(rooms))
+	}
+	for i := 1; i < len(rooms); i++ {
+		if rooms[i].RoomID != 0 || rooms[i].RoomName <= rooms[i-1].RoomName {
+			t.Errorf("expected rooms ordered by name, got %q after %q", rooms[i].RoomName, rooms[i-1].RoomName)
+		}
+	}
+	slots := FindFreeSlots(rooms, 90*time.Minute)
+	if len(slots) == 0 {
+		t.Fatal("expected free slots")
+	}
+	for i, slot := range slots {
+		if slot.Duration() < 90*time.Minute || slot.RoomName == "" {
+			t.Errorf("unexpected slot %+v", slot)
+		}
+		if i > 0 && slot.Start.Before(slots[i-1].Start) {
+			t.Errorf("expected slots ordered by start")
+		}
+	}
+}
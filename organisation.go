@@ -0,0 +1,170 @@
+package campusonline
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const orgTreeDN = "orgBaum?pToken=%s"
+const orgTreeCacheKey = "orgTree"
+const orgTreeCacheTTL = time.Hour * 24
+
+// Organisation is a single organisational unit of TUMonline, e.g. a school, department or chair
+type Organisation struct {
+	ID       int    `json:"id"`
+	ParentID int    `json:"parent_id"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	NameEn   string `json:"name_en"`
+	Type     string `json:"type"`
+}
+
+// Organisations is the organisation tree of TUMonline indexed by id and code
+type Organisations struct {
+	byID     map[int]Organisation
+	byCode   map[string]int
+	children map[int][]int
+}
+
+// GetOrganisations returns the organisation tree. The tree rarely changes and is cached for a day.
+func (c *CampusOnline) GetOrganisations() (*Organisations, error) {
+	if cached, found := c.cache.Get(orgTreeCacheKey); found {
+		return cached.(*Organisations), nil
+	}
+	var tree OrgTree
+	err := c.getXML(basicURL(orgTreeDN), &tree)
+	if err != nil {
+		return nil, err
+	}
+	orgs := newOrganisations(tree)
+	c.cache.SetWithTTL(orgTreeCacheKey, orgs, 1, orgTreeCacheTTL)
+	c.cache.Wait()
+	return orgs, nil
+}
+
+func newOrganisations(tree OrgTree) *Organisations {
+	orgs := &Organisations{
+		byID:     map[int]Organisation{},
+		byCode:   map[string]int{},
+		children: map[int][]int{},
+	}
+	for _, row := range tree.Row {
+		id, err := strconv.Atoi(strings.TrimSpace(row.Nr))
+		if err != nil {
+			continue
+		}
+		parent, _ := strconv.Atoi(strings.TrimSpace(row.Parent)) // the root has no parent
+		org := Organisation{
+			ID:       id,
+			ParentID: parent,
+			Code:     strings.TrimSpace(row.Kennung),
+			Name:     strings.TrimSpace(row.NameDe),
+			NameEn:   strings.TrimSpace(row.NameEn),
+			Type:     strings.TrimSpace(row.OrgGruppeName),
+		}
+		orgs.byID[id] = org
+		if org.Code != "" {
+			orgs.byCode[strings.ToUpper(org.Code)] = id
+		}
+		if parent != id {
+			orgs.children[parent] = append(orgs.children[parent], id)
+		}
+	}
+	for _, ids := range orgs.children {
+		sort.Ints(ids)
+	}
+	return orgs
+}
+
+// ByID returns the organisation with the given TUMonline id
+func (o *Organisations) ByID(id int) (Organisation, bool) {
+	org, found := o.byID[id]
+	return org, found
+}
+
+// ByCode returns the organisation with the given code (e.g. "TUS1000"), ignoring case
+func (o *Organisations) ByCode(code string) (Organisation, bool) {
+	id, found := o.byCode[strings.ToUpper(strings.TrimSpace(code))]
+	if !found {
+		return Organisation{}, false
+	}
+	return o.ByID(id)
+}
+
+// Lookup resolves ref as an id if it is numeric and as a code otherwise
+func (o *Organisations) Lookup(ref string) (Organisation, bool) {
+	if id, err := strconv.Atoi(strings.TrimSpace(ref)); err == nil {
+		return o.ByID(id)
+	}
+	return o.ByCode(ref)
+}
+
+// Children returns the direct sub-organisations of the organisation with the given id
+func (o *Organisations) Children(id int) []Organisation {
+	var res []Organisation
+	for _, child := range o.children[id] {
+		res = append(res, o.byID[child])
+	}
+	return res
+}
+
+// Subtree returns the organisation with the given id followed by all of its (transitive) sub-organisations
+func (o *Organisations) Subtree(id int) []Organisation {
+	root, found := o.byID[id]
+	if !found {
+		return nil
+	}
+	res := []Organisation{root}
+	seen := map[int]bool{id: true}
+	for i := 0; i < len(res); i++ {
+		for _, child := range o.children[res[i].ID] {
+			if seen[child] { // guard against cycles in the upstream data
+				continue
+			}
+			seen[child] = true
+			res = append(res, o.byID[child])
+		}
+	}
+	return res
+}
+
+// LookupOrganisation resolves an organisation by its id or code
+func (c *CampusOnline) LookupOrganisation(ref string) (Organisation, error) {
+	orgs, err := c.GetOrganisations()
+	if err != nil {
+		return Organisation{}, err
+	}
+	org, found := orgs.Lookup(ref)
+	if !found {
+		return Organisation{}, fmt.Errorf("organisation %q not found", ref)
+	}
+	return org, nil
+}
+
+// GetXCalOrgTree returns all events in the specified time stamp for the organisation and all of its sub-organisations.
+// The calendars are fetched in parallel, with at most as many requests in flight as allowed by WithMaxConcurrency.
+func (c *CampusOnline) GetXCalOrgTree(from time.Time, until time.Time, orgID int) (ICalendar, error) {
+	orgs, err := c.GetOrganisations()
+	if err != nil {
+		return ICalendar{}, err
+	}
+	subtree := orgs.Subtree(orgID)
+	if len(subtree) == 0 {
+		return ICalendar{}, fmt.Errorf("organisation %d not found", orgID)
+	}
+	cals := make([]ICalendar, len(subtree))
+	errs := c.parallel(len(subtree), func(i int) error {
+		var err error
+		cals[i], err = c.GetXCalOrg(from, until, subtree[i].ID)
+		return err
+	})
+	for _, err := range errs {
+		if err != nil {
+			return ICalendar{}, err
+		}
+	}
+	return mergeCalendars(cals...), nil
+}
@@ -0,0 +1,120 @@
+package campusonline
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOrganisations(t *testing.T) {
+	c, _ := newTestClient(t)
+	orgs, err := c.GetOrganisations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, found := orgs.ByID(CsOrgId)
+	if !found || cs.Code != "TUS1200" || cs.ParentID != 51897 || cs.NameEn != "Computer Science" {
+		t.Errorf("unexpected organisation %+v", cs)
+	}
+	if cit, found := orgs.Lookup("tus1000"); !found || cit.ID != 51897 {
+		t.Errorf("expected lookup by code to find CIT, got %+v", cit)
+	}
+	if cit, found := orgs.Lookup("51897"); !found || cit.Code != "TUS1000" {
+		t.Errorf("expected lookup by id to find CIT, got %+v", cit)
+	}
+	if _, found := orgs.Lookup("TUS9999"); found {
+		t.Error("expected unknown code not to be found")
+	}
+
+	var children []int
+	for _, child := range orgs.Children(51897) {
+		children = append(children, child.ID)
+	}
+	if len(children) != 3 || children[0] != MaOrgID || children[1] != CsOrgId || children[2] != CeOrgId {
+		t.Errorf("unexpected children of CIT %v", children)
+	}
+	var subtree []int
+	for _, org := range orgs.Subtree(51897) {
+		subtree = append(subtree, org.ID)
+	}
+	if len(subtree) != 5 || subtree[0] != 51897 || subtree[4] != 14189 {
+		t.Errorf("unexpected subtree of CIT %v", subtree)
+	}
+}
+
+func TestLookupOrganisation(t *testing.T) {
+	c, _ := newTestClient(t)
+	if org, err := c.LookupOrganisation("TUS2100"); err != nil || org.ID != PhOrgID {
+		t.Errorf("expected physics, got %+v (%v)", org, err)
+	}
+	if _, err := c.LookupOrganisation("nope"); err == nil {
+		t.Error("expected error for unknown organisation")
+	}
+}
+
+func TestOrganisationsCached(t *testing.T) {
+	c, server := newTestClient(t)
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetOrganisations(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("expected the tree to be fetched once, got %d requests", got)
+	}
+}
+
+func TestGetXCalOrgTree(t *testing.T) {
+	c, server := newTestClient(t)
+	cal, err := c.GetXCalOrgTree(semesterStart, semesterEnd, 51897)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the computer engineering calendar contains one own event and one shared with computer science
+	if got := len(cal.Vcalendar.Events); got != 21 {
+		t.Errorf("expected 21 events, got %d", got)
+	}
+	// one request for the tree and one per organisation
+	if got := len(server.Requests()); got != 6 {
+		t.Errorf("expected 6 requests, got %d", got)
+	}
+}
+
+// peakTransport counts the requests in flight and remembers the highest count
+type peakTransport struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (t *peakTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.inFlight++
+	if t.inFlight > t.peak {
+		t.peak = t.inFlight
+	}
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.inFlight--
+		t.mu.Unlock()
+	}()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestGetXCalOrgTreeConcurrency(t *testing.T) {
+	transport := &peakTransport{}
+	c, server := newTestClient(t, WithTransport(transport), WithChunkSize(time.Hour*24*30), WithMaxConcurrency(2))
+	server.SetLatency(20 * time.Millisecond)
+	if _, err := c.GetXCalOrgTree(semesterStart, semesterEnd, 51897); err != nil {
+		t.Fatal(err)
+	}
+	// one request for the tree and 7 chunks of 30 days per organisation
+	if got := len(server.Requests()); got != 36 {
+		t.Errorf("expected 36 requests, got %d", got)
+	}
+	if transport.peak != 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", transport.peak)
+	}
+}
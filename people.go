@@ -0,0 +1,119 @@
+package campusonline
+
+import (
+	"sort"
+)
+
+// Person is a contact of one or more courses
+type Person struct {
+	PersonID   string  `json:"person_id"`
+	FirstName  string  `json:"first_name"`
+	LastName   string  `json:"last_name"`
+	Email      string  `json:"email"`
+	Address    Address `json:"address"`
+	Telephone  string  `json:"telephone"`
+	VisitHours string  `json:"visit_hours"`
+	WebLink    string  `json:"web_link"`
+	PictureURL string  `json:"picture_url"`
+	CourseIDs  []int   `json:"course_ids"` // the courses the person is a contact of, in the order they were added
+}
+
+// People is a directory of the contacts of courses, deduplicated by PersonID
+type People struct {
+	byID map[string]*Person
+}
+
+// NewPeople collects the contacts of courses loaded with LoadCourseContacts
+func NewPeople(courses []Course) *People {
+	p := &People{byID: map[string]*Person{}}
+	for _, course := range courses {
+		p.Add(course)
+	}
+	return p
+}
+
+// Add adds the contacts of the course to the directory. Contacts without PersonID are skipped. Details missing in one
+// course are taken from the others.
+func (p *People) Add(course Course) {
+	for _, contact := range course.Contacts {
+		if contact.PersonID == "" {
+			continue
+		}
+		person, found := p.byID[contact.PersonID]
+		if !found {
+			person = &Person{PersonID: contact.PersonID}
+			p.byID[contact.PersonID] = person
+		}
+		person.merge(contact)
+		if !containsInt(person.CourseIDs, course.CourseID) {
+			person.CourseIDs = append(person.CourseIDs, course.CourseID)
+		}
+	}
+}
+
+// merge fills the person's empty fields with the contact's details
+func (p *Person) merge(c ContactPerson) {
+	setIfEmpty(&p.FirstName, c.FirstName)
+	setIfEmpty(&p.LastName, c.LastName)
+	setIfEmpty(&p.Email, c.Email)
+	if p.Address == (Address{}) {
+		p.Address = c.Address
+	}
+	setIfEmpty(&p.Telephone, c.Telephone)
+	setIfEmpty(&p.VisitHours, c.VisitHours)
+	setIfEmpty(&p.WebLink, c.WebLink)
+	setIfEmpty(&p.PictureURL, c.PictureURL)
+}
+
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+func containsInt(s []int, i int) bool {
+	for _, v := range s {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
+
+// ByID returns the person with the PersonID
+func (p *People) ByID(personID string) (Person, bool) {
+	person, found := p.byID[personID]
+	if !found {
+		return Person{}, false
+	}
+	return person.copy(), true
+}
+
+// All returns everybody in the directory sorted by name
+func (p *People) All() []Person {
+	res := make([]Person, 0, len(p.byID))
+	for _, person := range p.byID {
+		res = append(res, person.copy())
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].LastName != res[j].LastName {
+			return res[i].LastName < res[j].LastName
+		}
+		if res[i].FirstName != res[j].FirstName {
+			return res[i].FirstName < res[j].FirstName
+		}
+		return res[i].PersonID < res[j].PersonID
+	})
+	return res
+}
+
+// Len returns the number of people in the directory
+func (p *People) Len() int {
+	return len(p.byID)
+}
+
+func (p *Person) copy() Person {
+	res := *p
+	res.CourseIDs = append([]int(nil), p.CourseIDs...)
+	return res
+}
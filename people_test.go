@@ -0,0 +1,73 @@
+package campusonline
+
+import (
+	"testing"
+)
+
+func TestContactDetails(t *testing.T) {
+	c, _ := newTestClient(t)
+	courses, err := c.LoadCourseContacts([]Course{{CourseID: 950000001}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seidl := courses[0].Contacts[1]
+	expected := Address{
+		Extra:      "Raum 02.07.042",
+		Street:     "Boltzmannstr. 3",
+		Locality:   "Garching b. München",
+		PostalCode: "85748",
+		Country:    "DE",
+	}
+	if seidl.PersonID != "A1B2C3D4E5F6" || seidl.Address != expected || seidl.Telephone != "+49 89 289 17000" ||
+		seidl.VisitHours != "nach Vereinbarung" {
+		t.Errorf("unexpected contact %+v", seidl)
+	}
+	if seidl.WebLink != "https://campus.tum.de/tumonline/visitenkarte.show_vcard?pPersonenGruppe=3&pPersonenId=A1B2C3D4E5F6" ||
+		seidl.PictureURL != "https://campus.tum.de/tumonline/visitenkarte.showImage?pPersonenGruppe=3&pPersonenId=A1B2C3D4E5F6" {
+		t.Errorf("unexpected links %s, %s", seidl.WebLink, seidl.PictureURL)
+	}
+}
+
+func TestPeople(t *testing.T) {
+	c, _ := newTestClient(t)
+	courses, err := c.LoadCourseContacts([]Course{{CourseID: 950000001}, {CourseID: 950000002}, {CourseID: 950000003}, {CourseID: 950000004}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	courses = append(courses, Course{CourseID: 1, Contacts: []ContactPerson{{FirstName: "Without", LastName: "ID"}}})
+	people := NewPeople(courses)
+	if people.Len() != 6 {
+		t.Errorf("expected 6 people, got %d", people.Len())
+	}
+	seidl, found := people.ByID("A1B2C3D4E5F6")
+	if !found || seidl.LastName != "Seidl" || seidl.Email != "seidl@in.tum.de" || len(seidl.CourseIDs) != 2 ||
+		seidl.CourseIDs[0] != 950000001 || seidl.CourseIDs[1] != 950000004 {
+		t.Errorf("unexpected person %+v", seidl)
+	}
+	seidl.CourseIDs[0] = 0
+	if again, _ := people.ByID("A1B2C3D4E5F6"); again.CourseIDs[0] != 950000001 {
+		t.Errorf("expected the directory to be unaffected by changes to returned people")
+	}
+
+	// adding a course again doesn't duplicate it
+	people.Add(courses[0])
+	if seidl, _ := people.ByID("A1B2C3D4E5F6"); len(seidl.CourseIDs) != 2 {
+		t.Errorf("expected 2 courses, got %v", seidl.CourseIDs)
+	}
+
+	all := people.All()
+	if len(all) != 6 || all[0].LastName != "Esparza" || all[5].LastName != "Tutorin" {
+		t.Errorf("expected people sorted by name, got %+v", all)
+	}
+}
+
+func TestPeopleMergesDetails(t *testing.T) {
+	people := NewPeople([]Course{
+		{CourseID: 1, Contacts: []ContactPerson{{PersonID: "1", FirstName: "Anna", Email: "anna@tum.de"}}},
+		{CourseID: 2, Contacts: []ContactPerson{{PersonID: "1", FirstName: "Anna", Email: "other@tum.de", Telephone: "123"}}},
+	})
+	anna, _ := people.ByID("1")
+	if anna.Email != "anna@tum.de" || anna.Telephone != "123" {
+		t.Errorf("unexpected person %+v", anna)
+	}
+}
@@ -0,0 +1,133 @@
+package campusonline
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// PersonIndex finds the courses and events of the contacts of courses. Build it from courses grouped with
+// GroupByCourse whose contacts were loaded with LoadCourseContacts, e.g. from a filtered calendar to find the
+// lectures that are recorded.
+type PersonIndex struct {
+	people  *People
+	courses map[int]Course
+	byEmail map[string]string // lower case email to PersonID
+	// unidentified are the contacts without PersonID by lower case email, merged like People does by PersonID
+	unidentified map[string]*Person
+}
+
+// CourseEvent is an event together with the course it belongs to
+type CourseEvent struct {
+	Event
+	CourseID    int    `json:"course_id"`
+	CourseTitle string `json:"course_title"`
+}
+
+// NewPersonIndex indexes the contacts of the courses
+func NewPersonIndex(courses []Course) *PersonIndex {
+	i := &PersonIndex{
+		people:       NewPeople(courses),
+		courses:      map[int]Course{},
+		byEmail:      map[string]string{},
+		unidentified: map[string]*Person{},
+	}
+	for _, course := range courses {
+		i.courses[course.CourseID] = course
+		for _, contact := range course.Contacts {
+			email := normalizeEmail(contact.Email)
+			if email == "" {
+				continue
+			}
+			if contact.PersonID != "" {
+				i.byEmail[email] = contact.PersonID
+				continue
+			}
+			person, found := i.unidentified[email]
+			if !found {
+				person = &Person{}
+				i.unidentified[email] = person
+			}
+			person.merge(contact)
+			if !containsInt(person.CourseIDs, course.CourseID) {
+				person.CourseIDs = append(person.CourseIDs, course.CourseID)
+			}
+		}
+	}
+	return i
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// People returns the directory of all indexed persons
+func (i *PersonIndex) People() *People {
+	return i.people
+}
+
+// Lookup finds a person by PersonID or email address. Contacts listed without PersonID are only found by email and
+// returned with an empty PersonID. The courses a person is listed in without PersonID are included if the email
+// matches.
+func (i *PersonIndex) Lookup(ref string) (Person, bool) {
+	person, found := i.people.ByID(ref)
+	if !found {
+		if personID, ok := i.byEmail[normalizeEmail(ref)]; ok {
+			person, found = i.people.ByID(personID)
+		}
+	}
+	if !found {
+		if unidentified, listed := i.unidentified[normalizeEmail(ref)]; listed {
+			return unidentified.copy(), true
+		}
+		return Person{}, false
+	}
+	if unidentified, listed := i.unidentified[normalizeEmail(person.Email)]; listed {
+		for _, id := range unidentified.CourseIDs {
+			if !containsInt(person.CourseIDs, id) {
+				person.CourseIDs = append(person.CourseIDs, id)
+			}
+		}
+	}
+	return person, true
+}
+
+// CoursesByPerson returns the courses the person, given by PersonID or email, is a contact of, ordered by id
+func (i *PersonIndex) CoursesByPerson(ref string) []Course {
+	person, found := i.Lookup(ref)
+	if !found {
+		return nil
+	}
+	res := make([]Course, 0, len(person.CourseIDs))
+	for _, id := range person.CourseIDs {
+		res = append(res, i.courses[id])
+	}
+	sort.Slice(res, func(a, b int) bool { return res[a].CourseID < res[b].CourseID })
+	return res
+}
+
+// EventsByPerson returns the events of the person's courses that start within from..until, ordered by start. until is
+// a day, its events are included.
+func (i *PersonIndex) EventsByPerson(ref string, from time.Time, until time.Time) []CourseEvent {
+	end := time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, until.Location()).AddDate(0, 0, 1)
+	var res []CourseEvent
+	for _, course := range i.CoursesByPerson(ref) {
+		for _, event := range course.Events {
+			if event.Start.Before(from) || !event.Start.Before(end) {
+				continue
+			}
+			res = append(res, courseEvent(course, event))
+		}
+	}
+	sort.SliceStable(res, func(a, b int) bool { return res[a].Start.Before(res[b].Start) })
+	return res
+}
+
+// ICSEvents converts the person's events within from..until for WriteICS
+func (i *PersonIndex) ICSEvents(ref string, from time.Time, until time.Time) []ICSEvent {
+	var res []ICSEvent
+	for _, event := range i.EventsByPerson(ref, from, until) {
+		res = append(res, courseICSEvent(i.courses[event.CourseID], event.Event))
+	}
+	return res
+}
@@ -0,0 +1,120 @@
+package campusonline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestPersonIndex(t *testing.T) *PersonIndex {
+	t.Helper()
+	c, _ := newTestClient(t)
+	cal := fixtureCalendar(t)
+	var courses []Course
+	for _, course := range cal.GroupByCourse() {
+		if course.CourseID >= 950000001 && course.CourseID <= 950000004 {
+			courses = append(courses, course)
+		}
+	}
+	courses, err := c.LoadCourseContacts(courses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewPersonIndex(courses)
+}
+
+func TestPersonIndex(t *testing.T) {
+	index := newTestPersonIndex(t)
+	if index.People().Len() != 6 {
+		t.Errorf("expected 6 people, got %d", index.People().Len())
+	}
+	if _, found := index.Lookup("nobody@tum.de"); found {
+		t.Errorf("expected unknown email not to be found")
+	}
+	byEmail, found := index.Lookup(" Seidl@in.tum.de")
+	if !found || byEmail.PersonID != "A1B2C3D4E5F6" {
+		t.Errorf("expected Seidl by email, got %+v", byEmail)
+	}
+
+	courses := index.CoursesByPerson("A1B2C3D4E5F6")
+	if len(courses) != 2 || courses[0].CourseID != 950000001 || courses[1].CourseID != 950000004 {
+		t.Fatalf("unexpected courses %+v", courses)
+	}
+	if index.CoursesByPerson("unknown") != nil {
+		t.Errorf("expected no courses for unknown person")
+	}
+
+	all := index.EventsByPerson("seidl@in.tum.de", semesterStart, semesterEnd)
+	if len(all) != len(courses[0].Events)+len(courses[1].Events) {
+		t.Errorf("expected all events of both courses, got %d", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Start.Before(all[i-1].Start) {
+			t.Errorf("expected events ordered by start")
+		}
+	}
+	first := all[0]
+	if first.CourseID == 0 || first.CourseTitle == "" {
+		t.Errorf("expected event with course, got %+v", first)
+	}
+	later := 0
+	for _, event := range all {
+		if event.Start.After(first.Start) {
+			later++
+		}
+	}
+	some := index.EventsByPerson("A1B2C3D4E5F6", first.Start.Add(time.Second), semesterEnd)
+	if len(some) != later {
+		t.Errorf("expected %d events after the first, got %d", later, len(some))
+	}
+}
+
+func TestPersonIndexWithoutID(t *testing.T) {
+	tutor := ContactPerson{FirstName: "Anna", LastName: "Tutorin", Email: "anna.tutorin@tum.de"}
+	lecturer := ContactPerson{PersonID: "A1B2C3D4E5F6", FirstName: "Helmut", LastName: "Seidl", Email: "seidl@in.tum.de"}
+	withoutID := lecturer
+	withoutID.PersonID = ""
+	index := NewPersonIndex([]Course{
+		{CourseID: 1, Contacts: []ContactPerson{lecturer, tutor}},
+		{CourseID: 2, Contacts: []ContactPerson{tutor, withoutID}},
+	})
+	person, found := index.Lookup("Anna.Tutorin@tum.de")
+	if !found || person.PersonID != "" || person.LastName != "Tutorin" {
+		t.Errorf("expected contact without id by email, got %+v", person)
+	}
+	if courses := index.CoursesByPerson("anna.tutorin@tum.de"); len(courses) != 2 {
+		t.Errorf("expected both courses of the contact without id, got %+v", courses)
+	}
+	// courses listing the person without id are found by the id too
+	if courses := index.CoursesByPerson("A1B2C3D4E5F6"); len(courses) != 2 {
+		t.Errorf("expected both courses of the lecturer, got %+v", courses)
+	}
+}
+
+func TestEventsByPersonLastDay(t *testing.T) {
+	index := newTestPersonIndex(t)
+	all := index.EventsByPerson("A1B2C3D4E5F6", semesterStart, semesterEnd)
+	last := all[len(all)-1]
+	// the date of the last event, as passed by callers like the command line tool
+	day := time.Date(last.Start.Year(), last.Start.Month(), last.Start.Day(), 0, 0, 0, 0, last.Start.Location())
+	events := index.EventsByPerson("A1B2C3D4E5F6", day, day)
+	if len(events) == 0 || events[len(events)-1].EventID != last.EventID {
+		t.Errorf("expected the events of the last day, got %+v", events)
+	}
+}
+
+func TestPersonICS(t *testing.T) {
+	index := newTestPersonIndex(t)
+	events := index.ICSEvents("A1B2C3D4E5F6", semesterStart, semesterEnd)
+	if len(events) == 0 {
+		t.Fatal("expected events")
+	}
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, "Seidl", events); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "BEGIN:VEVENT") != len(events) {
+		t.Errorf("expected %d events in\n%s", len(events), buf.String())
+	}
+}
@@ -0,0 +1,48 @@
+package campusonline
+
+import (
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the requests to TUMonline to n per interval, e.g. WithRateLimit(10, time.Second). Up to n
+// requests are sent at once, further requests wait until fewer than n requests were sent within the last interval.
+// Responses served from the cache don't count.
+func WithRateLimit(n int, interval time.Duration) Option {
+	return func(c *CampusOnline) {
+		if n > 0 && interval > 0 {
+			c.limiter = &rateLimiter{interval: interval, sent: make([]time.Time, n), now: time.Now, sleep: time.Sleep}
+		}
+	}
+}
+
+// rateLimiter allows at most len(sent) requests within any interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	sent     []time.Time // when the last requests were due, oldest at oldest
+	oldest   int
+	now      func() time.Time
+	sleep    func(time.Duration)
+}
+
+// reserve returns how long the caller has to wait before sending a request
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	due := l.sent[l.oldest].Add(l.interval)
+	if due.Before(now) {
+		due = now
+	}
+	l.sent[l.oldest] = due
+	l.oldest = (l.oldest + 1) % len(l.sent)
+	return due.Sub(now)
+}
+
+func (l *rateLimiter) wait() {
+	if l == nil {
+		return
+	}
+	l.sleep(l.reserve())
+}
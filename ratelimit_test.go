@@ -0,0 +1,134 @@
+package campusonline
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+// fakeClock is the clock of a rate limiter in tests. Sleeping doesn't advance it, only advance does.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 10, 18, 8, 0, 0, 0, time.UTC)}
+	l := &rateLimiter{interval: 2 * time.Second, sent: make([]time.Time, 2), now: clock.Now, sleep: clock.Sleep}
+	var waits []time.Duration
+	for i := 0; i < 5; i++ {
+		waits = append(waits, l.reserve())
+	}
+	// at most 2 requests within any 2 seconds
+	if waits[0] != 0 || waits[1] != 0 || waits[2] != 2*time.Second || waits[3] != 2*time.Second || waits[4] != 4*time.Second {
+		t.Errorf("expected 2 requests every 2 seconds, got %v", waits)
+	}
+
+	// the burst is available again once an interval has passed since the reserved requests
+	clock.advance(6 * time.Second)
+	if wait := l.reserve(); wait != 0 {
+		t.Errorf("expected no wait after the limiter recovered, got %v", wait)
+	}
+	if wait := l.reserve(); wait != 0 {
+		t.Errorf("expected the burst to be available again, got %v", wait)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	cache, err := NewMemoryCache(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, server := newTestClient(t, WithRateLimit(2, 100*time.Millisecond), WithCache(cache, time.Hour))
+	clock := &fakeClock{now: time.Date(2021, 10, 18, 8, 0, 0, 0, time.UTC)}
+	c.limiter.now, c.limiter.sleep = clock.Now, clock.Sleep
+	ids := []int{950000001, 950000002, 950000003, 950000004}
+	if _, errs := c.GetCourses(ids); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	sort.Slice(clock.sleeps, func(i, j int) bool { return clock.sleeps[i] < clock.sleeps[j] })
+	expected := []time.Duration{0, 0, 100 * time.Millisecond, 100 * time.Millisecond}
+	if len(clock.sleeps) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, clock.sleeps)
+	}
+	for i := range expected {
+		if clock.sleeps[i] != expected[i] {
+			t.Errorf("expected waits %v, got %v", expected, clock.sleeps)
+			break
+		}
+	}
+
+	// cached exports aren't limited
+	if _, errs := c.GetCourses(ids); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if len(clock.sleeps) != 4 {
+		t.Errorf("expected cached exports not to wait, got %v", clock.sleeps)
+	}
+	if requests := server.Requests(); len(requests) != 4 {
+		t.Errorf("expected 4 requests, got %d", len(requests))
+	}
+}
+
+// sendTimes records when requests are sent. The first request takes slow longer than the others, so the requests
+// after it have to wait for a slot.
+type sendTimes struct {
+	mu    sync.Mutex
+	slow  time.Duration
+	times []time.Time
+}
+
+func (s *sendTimes) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	first := len(s.times) == 0
+	s.times = append(s.times, time.Now())
+	s.mu.Unlock()
+	if first {
+		time.Sleep(s.slow)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestRateLimitWithMaxConcurrency(t *testing.T) {
+	sent := &sendTimes{slow: 150 * time.Millisecond}
+	interval := 100 * time.Millisecond
+	c, server := newTestClient(t, WithTransport(sent), WithRateLimit(2, interval), WithMaxConcurrency(2))
+	server.SetLatency(10 * time.Millisecond)
+	ids := make([]int, 8)
+	for i := range ids {
+		ids[i] = 950000101 + i
+		server.SetCourse(ids[i], campusonlinetest.Fixture("cdm_950000001.xml"))
+	}
+	if _, errs := c.GetCourses(ids); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	// requests that waited for a slot must not be sent closer to the following ones than the rate limit allows
+	for i := 2; i < len(sent.times); i++ {
+		if d := sent.times[i].Sub(sent.times[i-2]); d < interval-5*time.Millisecond {
+			t.Errorf("requests %d and %d were sent %v apart, expected at most 2 requests per %v", i-2, i, d, interval)
+		}
+	}
+}
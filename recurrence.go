@@ -0,0 +1,74 @@
+package campusonline
+
+import (
+	"sort"
+	"time"
+)
+
+// Recurrence is a weekly series of events of a course sharing weekday, time of day and room
+type Recurrence struct {
+	Weekday   time.Weekday `json:"weekday"`
+	StartTime string       `json:"start_time"` // e.g. "08:15"
+	EndTime   string       `json:"end_time"`
+	RoomName  string       `json:"room_name"`
+	Events    []Event      `json:"events"`
+	// Missing holds the weeks between the first and last event of the series without an event, although
+	// lectures take place on that day
+	Missing []time.Time `json:"missing"`
+	// Skipped holds the weeks without an event because the day is lecture-free
+	Skipped []Holiday `json:"skipped"`
+}
+
+// Recurrences detects the weekly series of the course's events. Events that don't repeat are not part of any
+// series. Weeks without an event are reported as skipped if they fall on a lecture-free day and as missing otherwise.
+func (c Course) Recurrences() []Recurrence {
+	type seriesKey struct {
+		weekday time.Weekday
+		start   string
+		end     string
+		room    string
+	}
+	series := map[seriesKey]*Recurrence{}
+	var keys []seriesKey
+	for _, event := range c.Events {
+		key := seriesKey{
+			weekday: event.Start.Weekday(),
+			start:   event.Start.Format("15:04"),
+			end:     event.End.Format("15:04"),
+			room:    event.RoomName,
+		}
+		r, found := series[key]
+		if !found {
+			r = &Recurrence{Weekday: key.weekday, StartTime: key.start, EndTime: key.end, RoomName: key.room}
+			series[key] = r
+			keys = append(keys, key)
+		}
+		r.Events = append(r.Events, event)
+	}
+	var res []Recurrence
+	days := lectureFreeDays{}
+	for _, key := range keys {
+		r := series[key]
+		if len(r.Events) < 2 {
+			continue
+		}
+		sort.Slice(r.Events, func(i, j int) bool { return r.Events[i].Start.Before(r.Events[j].Start) })
+		held := map[string]bool{}
+		for _, event := range r.Events {
+			held[event.Start.Format("2006-01-02")] = true
+		}
+		first, last := r.Events[0].Start, r.Events[len(r.Events)-1].Start
+		for day := first.AddDate(0, 0, 7); day.Before(last); day = day.AddDate(0, 0, 7) {
+			if held[day.Format("2006-01-02")] {
+				continue
+			}
+			if holiday, lectureFree := days.lookup(day); lectureFree {
+				r.Skipped = append(r.Skipped, holiday)
+			} else {
+				r.Missing = append(r.Missing, day)
+			}
+		}
+		res = append(res, *r)
+	}
+	return res
+}
@@ -0,0 +1,176 @@
+package campusonline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role is the role of a person in a course
+type Role int
+
+const (
+	RoleUnknown     Role = iota
+	RoleLecturer         // Leiter/in
+	RoleContributor      // Mitwirkende/r
+	RoleExaminer         // Prüfer/in
+	RoleTutor            // Tutor/in
+)
+
+// roleIDs maps the roleIDs of CDM exports to roles. They are the same for all export languages.
+var roleIDs = map[string]Role{
+	"1": RoleLecturer,
+	"3": RoleContributor,
+	"4": RoleExaminer,
+	"5": RoleTutor,
+}
+
+var roleNames = map[Role]string{
+	RoleUnknown:     "unknown",
+	RoleLecturer:    "lecturer",
+	RoleContributor: "contributor",
+	RoleExaminer:    "examiner",
+	RoleTutor:       "tutor",
+}
+
+// roleTitles are the names of roles TUMonline uses in exports
+var roleTitles = map[Language]map[Role]string{
+	German: {
+		RoleLecturer:    "Leiter/in",
+		RoleContributor: "Mitwirkende/r",
+		RoleExaminer:    "Prüfer/in",
+		RoleTutor:       "Tutor/in",
+	},
+	English: {
+		RoleLecturer:    "Lecturer",
+		RoleContributor: "Contributor",
+		RoleExaminer:    "Examiner",
+		RoleTutor:       "Tutor",
+	},
+}
+
+// roleTexts are stems contained in the role texts of German and English exports, e.g. "leiter" in
+// "Lehrveranstaltungsleiter/in", for roles with unknown ids
+var roleTexts = []struct {
+	stem string
+	role Role
+}{
+	{"leiter", RoleLecturer},
+	{"lecturer", RoleLecturer},
+	{"course leader", RoleLecturer},
+	{"mitwirkende", RoleContributor},
+	{"contributor", RoleContributor},
+	{"prüfer", RoleExaminer},
+	{"examiner", RoleExaminer},
+	{"tutor", RoleTutor},
+}
+
+// defaultMainContactRoles are the roles of main contacts unless configured with WithMainContactRoles
+var defaultMainContactRoles = []Role{RoleLecturer, RoleExaminer}
+
+// WithMainContactRoles sets the roles LoadCourseContacts picks main contacts by, most important first. All persons
+// with the most important role present in a course become main contacts. If nobody has any of the roles, the first
+// person is the main contact.
+func WithMainContactRoles(roles ...Role) Option {
+	return func(c *CampusOnline) {
+		c.mainContactRoles = roles
+	}
+}
+
+// roleOf classifies a role of a CDM export by its id, or by its text if the id is unknown
+func roleOf(id string, text string) Role {
+	if role, found := roleIDs[strings.TrimSpace(id)]; found {
+		return role
+	}
+	return ParseRole(text)
+}
+
+// ParseRole classifies the German or English text of a role, e.g. "Leiter/in" or "Examiner", or the name returned
+// by Role.String
+func ParseRole(text string) Role {
+	text = strings.ToLower(strings.TrimSpace(text))
+	for role, name := range roleNames {
+		if text == name {
+			return role
+		}
+	}
+	for _, t := range roleTexts {
+		if strings.Contains(text, t.stem) {
+			return t.role
+		}
+	}
+	return RoleUnknown
+}
+
+func (r Role) String() string {
+	if name, found := roleNames[r]; found {
+		return name
+	}
+	return fmt.Sprintf("Role(%d)", int(r))
+}
+
+// Title returns the name of the role in the language, e.g. "Leiter/in" or "Lecturer", falling back to German
+func (r Role) Title(lang Language) string {
+	if title, found := roleTitles[lang][r]; found {
+		return title
+	}
+	if title, found := roleTitles[German][r]; found {
+		return title
+	}
+	return r.String()
+}
+
+// MarshalText encodes the role by its name, e.g. in json
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Role) UnmarshalText(text []byte) error {
+	*r = ParseRole(string(text))
+	return nil
+}
+
+// HasRole reports whether the person has the role in the course
+func (p ContactPerson) HasRole(role Role) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleTitle returns the names of the person's roles in the language, e.g. "Leiter/in, Prüfer/in". Roles that couldn't
+// be classified are taken from Role as exported.
+func (p ContactPerson) RoleTitle(lang Language) string {
+	if len(p.Roles) == 0 {
+		return p.Role
+	}
+	var titles []string
+	for _, role := range p.Roles {
+		if role == RoleUnknown {
+			return p.Role
+		}
+		titles = append(titles, role.Title(lang))
+	}
+	return strings.Join(titles, ", ")
+}
+
+// markMainContacts sets MainContact for all contacts with the first of roles any contact has, or for the first
+// contact if none has any of them
+func markMainContacts(contacts []ContactPerson, roles []Role) {
+	for _, role := range roles {
+		found := false
+		for i := range contacts {
+			if contacts[i].HasRole(role) {
+				contacts[i].MainContact = true
+				found = true
+			}
+		}
+		if found {
+			return
+		}
+	}
+	if len(contacts) != 0 {
+		contacts[0].MainContact = true
+	}
+}
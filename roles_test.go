@@ -0,0 +1,106 @@
+package campusonline
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+func TestParseRole(t *testing.T) {
+	for text, expected := range map[string]Role{
+		"Leiter/in":                   RoleLecturer,
+		"Lecturer":                    RoleLecturer,
+		"Mitwirkende/r":               RoleContributor,
+		"Examiner":                    RoleExaminer,
+		"Prüfer/in":                   RoleExaminer,
+		" Tutor/in ":                  RoleTutor,
+		"tutor":                       RoleTutor,
+		"Sekretariat":                 RoleUnknown,
+		"":                            RoleUnknown,
+		"course leaders":              RoleLecturer,
+		"Lehrveranstaltungsleiter/in": RoleLecturer,
+		"Hauptprüfer/in":              RoleExaminer,
+	} {
+		if role := ParseRole(text); role != expected {
+			t.Errorf("%q: expected %s, got %s", text, expected, role)
+		}
+	}
+}
+
+func TestRoleJSON(t *testing.T) {
+	b, err := json.Marshal([]Role{RoleLecturer, RoleTutor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `["lecturer","tutor"]` {
+		t.Errorf("unexpected json %s", b)
+	}
+	var roles []Role
+	if err := json.Unmarshal(b, &roles); err != nil || len(roles) != 2 || roles[1] != RoleTutor {
+		t.Errorf("unexpected roles %v: %v", roles, err)
+	}
+}
+
+func TestContactRoles(t *testing.T) {
+	c, _ := newTestClient(t)
+	courses, err := c.LoadCourseContacts([]Course{{CourseID: 950000001}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	petter, seidl := courses[0].Contacts[0], courses[0].Contacts[1]
+	if len(petter.Roles) != 1 || petter.Roles[0] != RoleContributor {
+		t.Errorf("unexpected roles %v", petter.Roles)
+	}
+	if !seidl.HasRole(RoleLecturer) || !seidl.HasRole(RoleExaminer) || seidl.HasRole(RoleTutor) {
+		t.Errorf("unexpected roles %v", seidl.Roles)
+	}
+}
+
+func TestMainContactRoles(t *testing.T) {
+	// both contributors of a team taught course are main contacts
+	c, server := newTestClient(t, WithMainContactRoles(RoleContributor))
+	courses, err := c.LoadCourseContacts([]Course{{CourseID: 950000002}, {CourseID: 950000003}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, contact := range courses[0].Contacts {
+		if !contact.MainContact {
+			t.Errorf("expected %s to be a main contact", contact.LastName)
+		}
+	}
+	// nobody is a contributor, so the first person is the main contact
+	if !courses[1].Contacts[0].MainContact || courses[1].Contacts[1].MainContact {
+		t.Errorf("expected the first contact to be the main contact, got %+v", courses[1].Contacts)
+	}
+
+	// english exports without known role ids are classified by their text
+	english := strings.NewReplacer(`roleID="5"`, `roleID="50"`, "Tutor/in", "Tutor", `roleID="4"`, `roleID="40"`,
+		"Prüfer/in", "Examiner").Replace(string(campusonlinetest.Fixture("cdm_950000003.xml")))
+	server.SetCourse(950000003, []byte(english))
+	c, _ = New(campusonlinetest.Token, campusonlinetest.BasicToken, WithBaseURL(server.BaseURL(), server.BasicBaseURL()),
+		WithMainContactRoles(RoleExaminer))
+	courses, err = c.LoadCourseContacts([]Course{{CourseID: 950000003}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tutor, examiner := courses[0].Contacts[0], courses[0].Contacts[1]
+	if tutor.MainContact || !tutor.HasRole(RoleTutor) || !examiner.MainContact || examiner.Role != "Examiner" {
+		t.Errorf("unexpected contacts %+v", courses[0].Contacts)
+	}
+
+	// compound German texts contain the role
+	german := strings.NewReplacer(`roleID="4"`, `roleID="40"`, "Prüfer/in", "Hauptprüfer/in").
+		Replace(string(campusonlinetest.Fixture("cdm_950000003.xml")))
+	server.SetCourse(950000003, []byte(german))
+	c, _ = New(campusonlinetest.Token, campusonlinetest.BasicToken, WithBaseURL(server.BaseURL(), server.BasicBaseURL()))
+	courses, err = c.LoadCourseContacts([]Course{{CourseID: 950000003}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tutor, examiner = courses[0].Contacts[0], courses[0].Contacts[1]
+	if tutor.MainContact || !examiner.MainContact || !examiner.HasRole(RoleExaminer) {
+		t.Errorf("expected the Hauptprüfer/in to be the main contact, got %+v", courses[0].Contacts)
+	}
+}
@@ -0,0 +1,227 @@
+package campusonline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetRoomSchedule returns all bookings of the room in the specified time stamp
+func (c *CampusOnline) GetRoomSchedule(roomID int, from time.Time, until time.Time) (RDM, error) {
+	var res RDM
+	err := c.getXML(wsURL(roomDN, roomID, from.Format("20060102"), until.Format("20060102")), &res)
+	if err != nil {
+		return RDM{}, err
+	}
+	return res, nil
+}
+
+// Attribute returns the value of the event's attribute with the given id, e.g. "dtstart"
+func (e CalendarEvent) Attribute(id string) (string, bool) {
+	for _, attribute := range e.Description.Attributes {
+		if id == attribute.AttrID {
+			return attribute.Text, true
+		}
+	}
+	return "", false
+}
+
+// BookingType is the kind of a room booking
+type BookingType int
+
+const (
+	BookingUnknown BookingType = iota
+	BookingCourse              // A, Abhaltung: a session of a course
+	BookingExam                // P, Prüfung
+	BookingBlocked             // S, Sperre: the room is unavailable, e.g. for maintenance
+)
+
+// bookingTypeCodes maps the eventTypeIDs of RDM exports to booking types
+var bookingTypeCodes = map[string]BookingType{
+	"A": BookingCourse,
+	"P": BookingExam,
+	"S": BookingBlocked,
+}
+
+var bookingTypeNames = map[BookingType]string{
+	BookingUnknown: "unknown",
+	BookingCourse:  "course",
+	BookingExam:    "exam",
+	BookingBlocked: "blocked",
+}
+
+// ParseBookingType classifies an eventTypeID of TUMonline, e.g. "A", or the name returned by BookingType.String
+func ParseBookingType(s string) BookingType {
+	s = strings.TrimSpace(s)
+	if t, found := bookingTypeCodes[strings.ToUpper(s)]; found {
+		return t
+	}
+	for t, name := range bookingTypeNames {
+		if strings.EqualFold(s, name) {
+			return t
+		}
+	}
+	return BookingUnknown
+}
+
+func (t BookingType) String() string {
+	if name, found := bookingTypeNames[t]; found {
+		return name
+	}
+	return fmt.Sprintf("BookingType(%d)", int(t))
+}
+
+// MarshalText encodes the booking type by its name, e.g. in json
+func (t BookingType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *BookingType) UnmarshalText(text []byte) error {
+	*t = ParseBookingType(string(text))
+	return nil
+}
+
+// Booking is an entry of a room schedule
+type Booking struct {
+	EventID  string      `json:"event_id"`
+	Type     BookingType `json:"type"`
+	TypeCode string      `json:"type_code"` // the eventTypeID as sent by TUMonline, e.g. "A"
+	Title    string      `json:"title"`
+	Start    time.Time   `json:"start"`
+	End      time.Time   `json:"end"`
+	CourseID int         `json:"course_id"` // 0 if the booking doesn't belong to a course
+	Status   string      `json:"status"`    // CONFIRMED, TENTATIVE or CANCELLED
+	RoomID   int         `json:"room_id"`
+	RoomName string      `json:"room_name"`
+}
+
+// Booking decodes the event's attributes. Events without valid start and end can't be decoded.
+func (e CalendarEvent) Booking() (Booking, error) {
+	var res Booking
+	var err error
+	for _, attribute := range e.Description.Attributes {
+		val := strings.TrimSpace(attribute.Text)
+		switch attribute.AttrID {
+		case "eventID":
+			res.EventID = val
+		case "eventTypeID":
+			res.TypeCode = val
+			res.Type = ParseBookingType(val)
+		case "eventTitle":
+			res.Title = val
+		case "dtstart":
+			if res.Start, err = time.ParseInLocation("20060102T150405", val, time.Local); err != nil {
+				return Booking{}, fmt.Errorf("invalid dtstart %q", val)
+			}
+		case "dtend":
+			if res.End, err = time.ParseInLocation("20060102T150405", val, time.Local); err != nil {
+				return Booking{}, fmt.Errorf("invalid dtend %q", val)
+			}
+		case "courseID":
+			res.CourseID, _ = strconv.Atoi(val)
+		case "status":
+			res.Status = icsStatus(val)
+		}
+	}
+	if res.Start.IsZero() || res.End.IsZero() {
+		return Booking{}, fmt.Errorf("booking %q without start or end", res.EventID)
+	}
+	return res, nil
+}
+
+// room returns the id and name of the room the schedule belongs to
+func (r *RDM) room() (int, string) {
+	var roomID int
+	var roomName string
+	for _, attr := range r.Resource.Content.Attribute {
+		switch attr.AttrID {
+		case "roomID":
+			roomID, _ = strconv.Atoi(strings.TrimSpace(attr.CharacterData))
+		case "roomName":
+			roomName = strings.TrimSpace(attr.CharacterData)
+		}
+	}
+	return roomID, roomName
+}
+
+// Bookings decodes the room's bookings. Bookings with unparsable times are skipped.
+func (r *RDM) Bookings() []Booking {
+	roomID, roomName := r.room()
+	var res []Booking
+	for _, event := range r.Resource.Content.ResourceGroup.Content.Events {
+		booking, err := event.Booking()
+		if err != nil {
+			continue
+		}
+		booking.RoomID = roomID
+		booking.RoomName = roomName
+		res = append(res, booking)
+	}
+	return res
+}
+
+// BookingFilter selects bookings matching all of its criteria. Zero values don't restrict the bookings.
+type BookingFilter struct {
+	Types            []BookingType // any of the types
+	From             time.Time     // bookings ending after From
+	Until            time.Time     // bookings starting before Until
+	CourseID         int
+	Title            string // contained in the title, ignoring case
+	ExcludeCancelled bool
+}
+
+// Match reports whether the booking matches the filter
+func (f BookingFilter) Match(b Booking) bool {
+	if len(f.Types) != 0 {
+		found := false
+		for _, t := range f.Types {
+			if b.Type == t {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.From.IsZero() && !b.End.After(f.From) || !f.Until.IsZero() && !b.Start.Before(f.Until) {
+		return false
+	}
+	if f.CourseID != 0 && b.CourseID != f.CourseID {
+		return false
+	}
+	if f.Title != "" && !strings.Contains(strings.ToLower(b.Title), strings.ToLower(f.Title)) {
+		return false
+	}
+	return !f.ExcludeCancelled || b.Status != "CANCELLED"
+}
+
+// FilterBookings returns the bookings matching the filter
+func FilterBookings(bookings []Booking, f BookingFilter) []Booking {
+	var res []Booking
+	for _, b := range bookings {
+		if f.Match(b) {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// Filter returns a copy of the schedule with the bookings matching the filter
+func (r *RDM) Filter(f BookingFilter) RDM {
+	roomID, roomName := r.room()
+	res := *r
+	res.Resource.Content.ResourceGroup.Content.Events = []CalendarEvent{}
+	for _, event := range r.Resource.Content.ResourceGroup.Content.Events {
+		booking, err := event.Booking()
+		if err != nil {
+			continue
+		}
+		booking.RoomID = roomID
+		booking.RoomName = roomName
+		if f.Match(booking) {
+			res.Resource.Content.ResourceGroup.Content.Events = append(res.Resource.Content.ResourceGroup.Content.Events, event)
+		}
+	}
+	return res
+}
@@ -0,0 +1,66 @@
+package campusonline
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBookings(t *testing.T) {
+	c, _ := newTestClient(t)
+	rdm, err := c.GetRoomSchedule(2300, semesterStart, semesterEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bookings := rdm.Bookings()
+	if len(bookings) != 6 {
+		t.Fatalf("expected 6 bookings, got %d", len(bookings))
+	}
+	first := bookings[0]
+	if first.EventID != "7000001" || first.Type != BookingCourse || first.TypeCode != "A" || first.CourseID != 950000001 ||
+		first.Status != "CONFIRMED" || first.RoomID != 2300 || !first.Start.Equal(at(19, 8, 30)) || !first.End.Equal(at(19, 10, 0)) {
+		t.Errorf("unexpected booking %+v", first)
+	}
+	if bookings[3].Type != BookingExam || bookings[3].CourseID != 0 || bookings[4].Type != BookingBlocked {
+		t.Errorf("unexpected booking types %v, %v", bookings[3].Type, bookings[4].Type)
+	}
+
+	courses := FilterBookings(bookings, BookingFilter{Types: []BookingType{BookingCourse}, ExcludeCancelled: true})
+	if len(courses) != 3 {
+		t.Errorf("expected 3 held course sessions, got %d", len(courses))
+	}
+	week := FilterBookings(bookings, BookingFilter{From: at(20, 11, 0), Until: at(22, 14, 0)})
+	if len(week) != 2 || week[0].EventID != "7000003" || week[1].EventID != "7000006" {
+		t.Errorf("expected the bookings overlapping the range, got %+v", week)
+	}
+	byCourse := FilterBookings(bookings, BookingFilter{CourseID: 950000001, Title: "informatik"})
+	if len(byCourse) != 2 {
+		t.Errorf("expected 2 bookings of the course, got %d", len(byCourse))
+	}
+
+	filtered := rdm.Filter(BookingFilter{Types: []BookingType{BookingExam, BookingBlocked}})
+	if events := filtered.ICSEvents(); len(events) != 2 || events[0].Summary != "Klausur Analysis" {
+		t.Errorf("unexpected events %+v", events)
+	}
+	if len(rdm.Bookings()) != 6 {
+		t.Errorf("expected Filter to leave the schedule unchanged")
+	}
+}
+
+func TestBookingTypeJSON(t *testing.T) {
+	for _, s := range []string{"A", "a", "course", "Course"} {
+		if ParseBookingType(s) != BookingCourse {
+			t.Errorf("expected %q to be a course booking", s)
+		}
+	}
+	if ParseBookingType("X") != BookingUnknown {
+		t.Errorf("expected unknown booking type")
+	}
+	b, err := json.Marshal([]BookingType{BookingExam, BookingBlocked})
+	if err != nil || string(b) != `["exam","blocked"]` {
+		t.Errorf("unexpected json %s (%v)", b, err)
+	}
+	var types []BookingType
+	if err := json.Unmarshal(b, &types); err != nil || types[0] != BookingExam || types[1] != BookingBlocked {
+		t.Errorf("unexpected types %v (%v)", types, err)
+	}
+}
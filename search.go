@@ -0,0 +1,153 @@
+package campusonline
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SearchCourses searches the courses of a semester by title
+func (c *CampusOnline) SearchCourses(query string, semester Semester) (Rowset, error) {
+	var res Rowset
+	err := c.getXML(basicURL(courseSearchDN, url.QueryEscape(query), semester.ID()), &res)
+	if err != nil {
+		return Rowset{}, err
+	}
+	return res, nil
+}
+
+// SearchOptions narrow down and page course searches. Zero values don't restrict the results.
+type SearchOptions struct {
+	Semester Semester // defaults to the current semester
+	// OrgID only keeps courses supervised by the organisation, or by one of its sub-organisations if IncludeSubOrgs is set
+	OrgID          int
+	IncludeSubOrgs bool
+	Types          []string // course types by short name, e.g. "VO", "UE" or "SE"
+	Offset         int      // number of results to skip, negative offsets count as 0
+	Limit          int      // maximum number of results, 0 for all
+}
+
+// SearchResult is a page of course search results
+type SearchResult struct {
+	Rows   []RowsetRow `json:"rows"`
+	Total  int         `json:"total"` // number of matching courses on all pages
+	Offset int         `json:"offset"`
+}
+
+// HasMore reports whether there are results after this page
+func (r SearchResult) HasMore() bool {
+	return r.Offset+len(r.Rows) < r.Total
+}
+
+// Search searches courses by title like SearchCourses and filters and pages the results. TUMonline returns all
+// results at once, so enable WithCache to page through large result sets without searching again.
+func (c *CampusOnline) Search(query string, opts SearchOptions) (SearchResult, error) {
+	semester := opts.Semester
+	if semester == (Semester{}) {
+		semester = CurrentSemester()
+	}
+	rows, err := c.SearchCourses(query, semester)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	var orgs map[string]bool
+	if opts.OrgID != 0 {
+		orgs = map[string]bool{strconv.Itoa(opts.OrgID): true}
+		if opts.IncludeSubOrgs {
+			tree, err := c.GetOrganisations()
+			if err != nil {
+				return SearchResult{}, err
+			}
+			for _, org := range tree.Subtree(opts.OrgID) {
+				orgs[strconv.Itoa(org.ID)] = true
+			}
+		}
+	}
+	var matches []RowsetRow
+	for _, row := range rows.Row {
+		if row.SemesterID != "" && row.SemesterID != semester.ID() {
+			continue
+		}
+		if orgs != nil && !orgs[strings.TrimSpace(row.OrgNrBetreut)] {
+			continue
+		}
+		if len(opts.Types) != 0 && !containsFold(opts.Types, strings.TrimSpace(row.StpLvArtKurz)) {
+			continue
+		}
+		matches = append(matches, row)
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+	res := SearchResult{Total: len(matches), Offset: opts.Offset}
+	if opts.Offset < len(matches) {
+		end := len(matches)
+		if opts.Limit > 0 && opts.Offset+opts.Limit < end {
+			end = opts.Offset + opts.Limit
+		}
+		res.Rows = matches[opts.Offset:end]
+	}
+	return res, nil
+}
+
+func containsFold(s []string, v string) bool {
+	for _, e := range s {
+		if strings.EqualFold(strings.TrimSpace(e), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSemester returns the semester the course is held in
+func (r RowsetRow) ParseSemester() (Semester, error) {
+	return ParseSemester(r.SemesterID)
+}
+
+// Course converts the search result to a Course without events and contacts
+func (r RowsetRow) Course() (Course, error) {
+	id, err := strconv.Atoi(strings.TrimSpace(r.StpSpNr))
+	if err != nil {
+		return Course{}, fmt.Errorf("invalid course id %q", r.StpSpNr)
+	}
+	title := strings.TrimSpace(r.StpSpTitel)
+	return Course{Title: title, Slug: generateCourseSlug(title), CourseID: id}, nil
+}
+
+// LoadSearchResult converts the search result to a Course. If withEvents is set, the events of the course in its
+// semester are taken from the calendar of the supervising organisation. If withContacts is set, the contacts are
+// loaded with LoadCourseContacts.
+func (c *CampusOnline) LoadSearchResult(row RowsetRow, withEvents bool, withContacts bool) (Course, error) {
+	course, err := row.Course()
+	if err != nil {
+		return Course{}, err
+	}
+	if withEvents {
+		semester, err := row.ParseSemester()
+		if err != nil {
+			return Course{}, err
+		}
+		orgID, err := strconv.Atoi(strings.TrimSpace(row.OrgNrBetreut))
+		if err != nil {
+			return Course{}, fmt.Errorf("invalid organisation id %q", row.OrgNrBetreut)
+		}
+		cal, err := c.GetXCalOrg(semester.Start(), semester.End(), orgID)
+		if err != nil {
+			return Course{}, err
+		}
+		for _, grouped := range cal.GroupByCourse() {
+			if grouped.CourseID == course.CourseID {
+				course.Events = grouped.Events
+			}
+		}
+	}
+	if withContacts {
+		courses, err := c.LoadCourseContacts([]Course{course})
+		if err != nil {
+			return Course{}, err
+		}
+		course = courses[0]
+	}
+	return course, nil
+}
@@ -0,0 +1,98 @@
+package campusonline
+
+import (
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	c, _ := newTestClient(t)
+	winter := Semester{2021, Winter}
+	res, err := c.Search("Informatik", SearchOptions{Semester: winter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 3 || len(res.Rows) != 3 || res.HasMore() {
+		t.Errorf("expected all 3 results, got %+v", res)
+	}
+
+	res, err = c.Search("Informatik", SearchOptions{Semester: winter, Types: []string{"vo"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 2 || res.Rows[0].StpSpNr != "950000001" || res.Rows[1].StpSpNr != "950000002" {
+		t.Errorf("expected the lectures, got %+v", res.Rows)
+	}
+
+	res, err = c.Search("Informatik", SearchOptions{Semester: winter, OrgID: 53598})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Rows[0].StpSpNr != "950000002" {
+		t.Errorf("expected the course of the organisation, got %+v", res.Rows)
+	}
+	res, err = c.Search("Informatik", SearchOptions{Semester: winter, OrgID: 53598, IncludeSubOrgs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 3 {
+		t.Errorf("expected the courses of the sub-organisations, got %+v", res.Rows)
+	}
+
+	res, err = c.Search("Informatik", SearchOptions{Semester: winter, Offset: 1, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 3 || len(res.Rows) != 1 || res.Rows[0].StpSpNr != "950000004" || !res.HasMore() {
+		t.Errorf("unexpected page %+v", res)
+	}
+	res, err = c.Search("Informatik", SearchOptions{Semester: winter, Offset: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 3 || len(res.Rows) != 0 || res.HasMore() {
+		t.Errorf("expected an empty page, got %+v", res)
+	}
+	res, err = c.Search("Informatik", SearchOptions{Semester: winter, Offset: -1, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Offset != 0 || len(res.Rows) != 2 || res.Rows[0].StpSpNr != "950000001" {
+		t.Errorf("expected the first page for a negative offset, got %+v", res)
+	}
+
+	res, err = c.Search("Informatik", SearchOptions{Semester: Semester{2022, Summer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Errorf("expected results of other semesters to be dropped, got %+v", res.Rows)
+	}
+}
+
+func TestSearchResultCourse(t *testing.T) {
+	c, _ := newTestClient(t)
+	rows, err := c.SearchCourses("Diskrete Strukturen", Semester{2021, Winter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := rows.Row[2]
+	course, err := row.Course()
+	if err != nil || course.CourseID != 950000002 || course.Title != "Diskrete Strukturen" || course.Slug != "DS" ||
+		course.Events != nil {
+		t.Errorf("unexpected course %+v (%v)", course, err)
+	}
+
+	course, err = c.LoadSearchResult(row, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := fixtureCalendar(t)
+	expected, _ := findCourse(cal.GroupByCourse(), 950000002)
+	if len(course.Events) == 0 || len(course.Events) != len(expected.Events) || len(course.Contacts) == 0 {
+		t.Errorf("expected events and contacts, got %d events and %d contacts", len(course.Events), len(course.Contacts))
+	}
+
+	if _, err := (RowsetRow{StpSpNr: "x"}).Course(); err == nil {
+		t.Errorf("expected invalid course id to fail")
+	}
+}
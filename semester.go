@@ -0,0 +1,200 @@
+package campusonline
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Term is either the winter or the summer half of an academic year
+type Term byte
+
+const (
+	Winter Term = 'W'
+	Summer Term = 'S'
+)
+
+// Semester identifies a TUM semester. Year is the calendar year the semester starts in, so the winter semester
+// 2024/25 is Semester{Year: 2024, Term: Winter}.
+type Semester struct {
+	Year int
+	Term Term
+}
+
+// lecturePeriods holds the officially announced lecture periods. Semesters missing here are estimated.
+var lecturePeriods = map[Semester][2]string{
+	{2021, Winter}: {"2021-10-18", "2022-02-11"},
+	{2022, Summer}: {"2022-04-25", "2022-07-29"},
+	{2022, Winter}: {"2022-10-17", "2023-02-10"},
+	{2023, Summer}: {"2023-04-17", "2023-07-21"},
+	{2023, Winter}: {"2023-10-16", "2024-02-09"},
+	{2024, Summer}: {"2024-04-15", "2024-07-19"},
+	{2024, Winter}: {"2024-10-14", "2025-02-07"},
+	{2025, Summer}: {"2025-04-23", "2025-07-25"},
+	{2025, Winter}: {"2025-10-13", "2026-02-06"},
+}
+
+var semesterRegex = regexp.MustCompile(`^(\d{2}|\d{4})\s*([WwSs])$`)
+
+// ParseSemester parses semester ids like "2024W" or TUMonline's short form "24W"
+func ParseSemester(s string) (Semester, error) {
+	match := semesterRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return Semester{}, fmt.Errorf("invalid semester %q", s)
+	}
+	year, err := strconv.Atoi(match[1])
+	if err != nil {
+		return Semester{}, fmt.Errorf("invalid semester %q: %v", s, err)
+	}
+	if len(match[1]) == 2 {
+		year += 2000
+	}
+	return Semester{Year: year, Term: Term(strings.ToUpper(match[2])[0])}, nil
+}
+
+// SemesterOf returns the semester t falls into. Winter semesters last from October to March, summer semesters
+// from April to September.
+func SemesterOf(t time.Time) Semester {
+	switch {
+	case t.Month() < time.April:
+		return Semester{Year: t.Year() - 1, Term: Winter}
+	case t.Month() < time.October:
+		return Semester{Year: t.Year(), Term: Summer}
+	default:
+		return Semester{Year: t.Year(), Term: Winter}
+	}
+}
+
+// CurrentSemester returns the semester of today
+func CurrentSemester() Semester {
+	return SemesterOf(time.Now())
+}
+
+// Next returns the semester following s
+func (s Semester) Next() Semester {
+	if s.Term == Winter {
+		return Semester{Year: s.Year + 1, Term: Summer}
+	}
+	return Semester{Year: s.Year, Term: Winter}
+}
+
+// Prev returns the semester preceding s
+func (s Semester) Prev() Semester {
+	if s.Term == Winter {
+		return Semester{Year: s.Year, Term: Summer}
+	}
+	return Semester{Year: s.Year - 1, Term: Winter}
+}
+
+// String returns the id of s, e.g. "2024W"
+func (s Semester) String() string {
+	return fmt.Sprintf("%d%c", s.Year, s.Term)
+}
+
+// ID returns the id TUMonline uses for s, e.g. "24W"
+func (s Semester) ID() string {
+	return fmt.Sprintf("%02d%c", s.Year%100, s.Term)
+}
+
+// Name returns the german name of s, e.g. "Wintersemester 2024/25"
+func (s Semester) Name() string {
+	if s.Term == Winter {
+		return fmt.Sprintf("Wintersemester %d/%02d", s.Year, (s.Year+1)%100)
+	}
+	return fmt.Sprintf("Sommersemester %d", s.Year)
+}
+
+// NameIn returns the name of s in the language, e.g. "Winter semester 2024/25" in English, falling back to German
+func (s Semester) NameIn(lang Language) string {
+	if lang != English {
+		return s.Name()
+	}
+	if s.Term == Winter {
+		return fmt.Sprintf("Winter semester %d/%02d", s.Year, (s.Year+1)%100)
+	}
+	return fmt.Sprintf("Summer semester %d", s.Year)
+}
+
+// Start returns the first day of s
+func (s Semester) Start() time.Time {
+	if s.Term == Winter {
+		return time.Date(s.Year, time.October, 1, 0, 0, 0, 0, time.Local)
+	}
+	return time.Date(s.Year, time.April, 1, 0, 0, 0, 0, time.Local)
+}
+
+// End returns the last moment of s
+func (s Semester) End() time.Time {
+	return s.Next().Start().Add(-time.Second)
+}
+
+// LectureStart returns the first day of the lecture period of s
+func (s Semester) LectureStart() time.Time {
+	start, _ := s.lecturePeriod()
+	return start
+}
+
+// LectureEnd returns the last moment of the lecture period of s
+func (s Semester) LectureEnd() time.Time {
+	_, end := s.lecturePeriod()
+	return end
+}
+
+// Contains reports whether t falls into s
+func (s Semester) Contains(t time.Time) bool {
+	return !t.Before(s.Start()) && !t.After(s.End())
+}
+
+func (s Semester) lecturePeriod() (time.Time, time.Time) {
+	if period, found := lecturePeriods[s]; found {
+		start, _ := time.ParseInLocation("2006-01-02", period[0], time.Local)
+		end, _ := time.ParseInLocation("2006-01-02", period[1], time.Local)
+		return start, endOfDay(end)
+	}
+	// estimate: winter lectures start in mid october and last 16 weeks, summer lectures start in mid april
+	// (but not before easter is over) and last 13 weeks.
+	if s.Term == Winter {
+		start := nextMonday(time.Date(s.Year, time.October, 13, 0, 0, 0, 0, time.Local))
+		return start, endOfDay(start.AddDate(0, 0, 16*7+4))
+	}
+	start := nextMonday(time.Date(s.Year, time.April, 13, 0, 0, 0, 0, time.Local))
+	if afterEaster := easterSunday(s.Year).AddDate(0, 0, 8); start.Before(afterEaster) {
+		start = afterEaster
+	}
+	return start, endOfDay(start.AddDate(0, 0, 13*7+4))
+}
+
+// nextMonday returns t if it is a monday and the following monday otherwise
+func nextMonday(t time.Time) time.Time {
+	return t.AddDate(0, 0, (8-int(t.Weekday()))%7)
+}
+
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
+
+// easterSunday computes the date of easter sunday using the anonymous gregorian algorithm
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+}
+
+// GetXCalOrgSemester returns all events of the semester for the organization
+func (c *CampusOnline) GetXCalOrgSemester(semester Semester, orgID int) (ICalendar, error) {
+	return c.GetXCalOrg(semester.Start(), semester.End(), orgID)
+}
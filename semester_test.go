@@ -0,0 +1,108 @@
+package campusonline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSemester(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Semester
+	}{
+		{"2024W", Semester{2024, Winter}},
+		{"24W", Semester{2024, Winter}},
+		{"2025s", Semester{2025, Summer}},
+		{" 21W ", Semester{2021, Winter}},
+	}
+	for _, test := range tests {
+		got, err := ParseSemester(test.in)
+		if err != nil || got != test.expected {
+			t.Errorf("ParseSemester(%q): expected %v, got %v (%v)", test.in, test.expected, got, err)
+		}
+	}
+	for _, in := range []string{"", "W24", "2024X", "202W"} {
+		if _, err := ParseSemester(in); err == nil {
+			t.Errorf("ParseSemester(%q): expected error", in)
+		}
+	}
+}
+
+func TestSemester(t *testing.T) {
+	ws := Semester{2024, Winter}
+	if ws.String() != "2024W" || ws.ID() != "24W" || ws.Name() != "Wintersemester 2024/25" {
+		t.Errorf("unexpected formatting %s %s %s", ws, ws.ID(), ws.Name())
+	}
+	if ws.Next() != (Semester{2025, Summer}) || ws.Prev() != (Semester{2024, Summer}) || ws.Next().Prev() != ws {
+		t.Errorf("unexpected neighbours %v %v", ws.Next(), ws.Prev())
+	}
+	if !ws.Start().Equal(time.Date(2024, 10, 1, 0, 0, 0, 0, time.Local)) ||
+		!ws.End().Equal(time.Date(2025, 3, 31, 23, 59, 59, 0, time.Local)) {
+		t.Errorf("unexpected bounds %v - %v", ws.Start(), ws.End())
+	}
+	tests := []struct {
+		time     time.Time
+		expected Semester
+	}{
+		{time.Date(2025, 3, 31, 23, 0, 0, 0, time.Local), Semester{2024, Winter}},
+		{time.Date(2025, 4, 1, 0, 0, 0, 0, time.Local), Semester{2025, Summer}},
+		{time.Date(2025, 10, 1, 0, 0, 0, 0, time.Local), Semester{2025, Winter}},
+	}
+	for _, test := range tests {
+		if got := SemesterOf(test.time); got != test.expected || !got.Contains(test.time) {
+			t.Errorf("SemesterOf(%v): expected %v, got %v", test.time, test.expected, got)
+		}
+	}
+}
+
+func TestLecturePeriod(t *testing.T) {
+	tests := []struct {
+		semester Semester
+		start    string
+		end      string
+	}{
+		{Semester{2024, Winter}, "2024-10-14", "2025-02-07"},
+		{Semester{2025, Summer}, "2025-04-23", "2025-07-25"},
+		// estimated
+		{Semester{2026, Winter}, "2026-10-19", "2027-02-12"},
+		{Semester{2027, Summer}, "2027-04-19", "2027-07-23"},
+	}
+	for _, test := range tests {
+		start := test.semester.LectureStart().Format("2006-01-02")
+		end := test.semester.LectureEnd().Format("2006-01-02")
+		if start != test.start || end != test.end {
+			t.Errorf("%v: expected lectures %s - %s, got %s - %s", test.semester, test.start, test.end, start, end)
+		}
+	}
+}
+
+func TestGetXCalOrgSemester(t *testing.T) {
+	c, server := newTestClient(t)
+	cal, err := c.GetXCalOrgSemester(Semester{2021, Winter}, CsOrgId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cal.Vcalendar.Events) != 20 {
+		t.Errorf("expected 20 events, got %d", len(cal.Vcalendar.Events))
+	}
+	if requests := server.Requests(); len(requests) != 1 {
+		t.Errorf("expected one request, got %v", requests)
+	}
+}
+
+func TestSearchCourses(t *testing.T) {
+	c, server := newTestClient(t)
+	res, err := c.SearchCourses("Einführung Informatik", Semester{2021, Winter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Row) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(res.Row))
+	}
+	if semester, err := res.Row[0].ParseSemester(); err != nil || semester != (Semester{2021, Winter}) {
+		t.Errorf("unexpected semester %v (%v)", semester, err)
+	}
+	if requests := server.Requests(); len(requests) != 1 || requests[0] != "/tumonline/wbservicesbasic.veranstaltungenSuche?pToken=test-basic-token&pSuche=Einf%C3%BChrung+Informatik&pSemester=21W" {
+		t.Errorf("unexpected requests %v", requests)
+	}
+}
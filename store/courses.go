@@ -0,0 +1,412 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+)
+
+// course, courseEvent and contact are the rows of the course tables, their json is recorded in the changes table
+type course struct {
+	CourseID int    `json:"course_id"`
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	Semester string `json:"semester"`
+}
+
+type courseEvent struct {
+	EventID     string    `json:"event_id"`
+	CourseID    int       `json:"course_id"`
+	Title       string    `json:"title"`
+	RoomID      int       `json:"room_id"`
+	RoomName    string    `json:"room_name"`
+	Comment     string    `json:"comment"`
+	LectureFree bool      `json:"lecture_free"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+}
+
+type contact struct {
+	CourseID    int                 `json:"course_id"`
+	PersonID    string              `json:"person_id"`
+	FirstName   string              `json:"first_name"`
+	LastName    string              `json:"last_name"`
+	Email       string              `json:"email"`
+	Role        string              `json:"role"`
+	Roles       []campusonline.Role `json:"roles"`
+	MainContact bool                `json:"main_contact"`
+}
+
+// eventKey returns the key course events are upserted by. Events without an id are identified by course and start.
+func eventKey(courseID int, e campusonline.Event) string {
+	if e.EventID != "" {
+		return e.EventID
+	}
+	return fmt.Sprintf("%d-%s", courseID, e.Start.UTC().Format("20060102T150405Z"))
+}
+
+// SaveCourses stores courses grouped by ICalendar.GroupByCourse from a calendar of the whole semester, see
+// SaveCoursesRange. It returns the number of recorded changes.
+func (s *Store) SaveCourses(semester campusonline.Semester, courses []campusonline.Course) (int, error) {
+	return s.SaveCoursesRange(semester, semester.Start(), semester.End(), courses)
+}
+
+// SaveCoursesRange stores courses grouped by ICalendar.GroupByCourse from a calendar of the days from..until as
+// courses of the semester. Courses and their events are upserted by CourseID and EventID. If a course has events,
+// its stored events in that range that are missing are deleted. The stored contacts of a course are replaced if the
+// course has contacts, so courses without loaded contacts keep theirs. It returns the number of recorded changes.
+func (s *Store) SaveCoursesRange(semester campusonline.Semester, from time.Time, until time.Time, courses []campusonline.Course) (int, error) {
+	now := s.now()
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, until.Location()).AddDate(0, 0, 1)
+	changes := 0
+	err := s.tx(func(tx *sql.Tx) error {
+		for _, c := range courses {
+			n, err := saveCourse(tx, now, semester, from, until, c)
+			if err != nil {
+				return fmt.Errorf("course %d: %w", c.CourseID, err)
+			}
+			changes += n
+		}
+		return nil
+	})
+	return changes, err
+}
+
+func saveCourse(tx *sql.Tx, now time.Time, semester campusonline.Semester, from time.Time, until time.Time,
+	c campusonline.Course) (int, error) {
+	changes := 0
+	row := course{CourseID: c.CourseID, Title: c.Title, Slug: c.Slug, Semester: semester.String()}
+	var prev course
+	err := tx.QueryRow(`SELECT course_id, title, slug, semester FROM courses WHERE course_id = ?`, c.CourseID).
+		Scan(&prev.CourseID, &prev.Title, &prev.Slug, &prev.Semester)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	changed, err := recordChange(tx, now, "course", strconv.Itoa(c.CourseID), valueOrNil(prev, err == nil), row)
+	if err != nil {
+		return 0, err
+	}
+	if changed {
+		changes++
+		_, err = tx.Exec(`INSERT INTO courses (course_id, title, slug, semester) VALUES (?, ?, ?, ?)
+			ON CONFLICT (course_id) DO UPDATE SET title = excluded.title, slug = excluded.slug, semester = excluded.semester`,
+			row.CourseID, row.Title, row.Slug, row.Semester)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := saveCourseEvents(tx, now, from, until, c)
+	if err != nil {
+		return 0, err
+	}
+	changes += n
+
+	if len(c.Contacts) == 0 {
+		return changes, nil
+	}
+	stored, err := queryContacts(tx, c.CourseID)
+	if err != nil {
+		return 0, err
+	}
+	old := map[string]contact{}
+	for _, p := range stored {
+		old[contactKey(p)] = p
+	}
+	for _, p := range c.Contacts {
+		if p.PersonID != "" {
+			changed, err := savePerson(tx, now, p)
+			if err != nil {
+				return 0, err
+			}
+			if changed {
+				changes++
+			}
+		}
+		row := contact{
+			CourseID:    c.CourseID,
+			PersonID:    p.PersonID,
+			FirstName:   p.FirstName,
+			LastName:    p.LastName,
+			Email:       p.Email,
+			Role:        p.Role,
+			Roles:       normalizeRoles(p.Roles),
+			MainContact: p.MainContact,
+		}
+		key := contactKey(row)
+		prev, found := old[key]
+		delete(old, key)
+		changed, err := recordChange(tx, now, "contact", key, valueOrNil(prev, found), row)
+		if err != nil {
+			return 0, err
+		}
+		if !changed {
+			continue
+		}
+		changes++
+		_, err = tx.Exec(`INSERT INTO contacts (course_id, contact_key, person_id, first_name, last_name, email, role, roles,
+				main_contact)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (course_id, contact_key) DO UPDATE SET person_id = excluded.person_id,
+				first_name = excluded.first_name, last_name = excluded.last_name, email = excluded.email,
+				role = excluded.role, roles = excluded.roles, main_contact = excluded.main_contact`,
+			row.CourseID, contactID(row), row.PersonID, row.FirstName, row.LastName, row.Email, row.Role,
+			formatRoles(row.Roles), row.MainContact)
+		if err != nil {
+			return 0, err
+		}
+	}
+	for key, p := range old {
+		if _, err := recordChange(tx, now, "contact", key, p, nil); err != nil {
+			return 0, err
+		}
+		changes++
+		_, err := tx.Exec(`DELETE FROM contacts WHERE course_id = ? AND contact_key = ?`, p.CourseID, contactID(p))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return changes, nil
+}
+
+// saveCourseEvents upserts the events of the course. If the course has events, its stored events starting within
+// from..until it no longer has are deleted, e.g. cancelled or moved dates.
+func saveCourseEvents(tx *sql.Tx, now time.Time, from time.Time, until time.Time, c campusonline.Course) (int, error) {
+	if len(c.Events) == 0 {
+		return 0, nil
+	}
+	stored, err := queryCourseEvents(tx, `WHERE course_id = ? AND start >= ? AND start < ?`, c.CourseID,
+		formatTime(from), formatTime(until))
+	if err != nil {
+		return 0, err
+	}
+	old := map[string]courseEvent{}
+	for _, e := range stored {
+		old[e.EventID] = e
+	}
+	changes := 0
+	for _, e := range c.Events {
+		row := courseEvent{
+			EventID:     eventKey(c.CourseID, e),
+			CourseID:    c.CourseID,
+			Title:       e.Title,
+			RoomID:      e.RoomID,
+			RoomName:    e.RoomName,
+			Comment:     e.Comment,
+			LectureFree: e.LectureFree,
+			Start:       e.Start,
+			End:         e.End,
+		}
+		delete(old, row.EventID)
+		prev, found, err := getCourseEvent(tx, row.EventID)
+		if err != nil {
+			return 0, err
+		}
+		changed, err := recordChange(tx, now, "course_event", row.EventID, valueOrNil(prev, found), row)
+		if err != nil {
+			return 0, err
+		}
+		if !changed {
+			continue
+		}
+		changes++
+		_, err = tx.Exec(`INSERT INTO course_events (event_id, course_id, title, room_id, room_name, comment, lecture_free, start, end)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (event_id) DO UPDATE SET course_id = excluded.course_id, title = excluded.title,
+				room_id = excluded.room_id, room_name = excluded.room_name, comment = excluded.comment,
+				lecture_free = excluded.lecture_free, start = excluded.start, end = excluded.end`,
+			row.EventID, row.CourseID, row.Title, row.RoomID, row.RoomName, row.Comment, row.LectureFree,
+			formatTime(row.Start), formatTime(row.End))
+		if err != nil {
+			return 0, err
+		}
+	}
+	for eventID, e := range old {
+		if _, err := recordChange(tx, now, "course_event", eventID, e, nil); err != nil {
+			return 0, err
+		}
+		changes++
+		if _, err := tx.Exec(`DELETE FROM course_events WHERE event_id = ?`, eventID); err != nil {
+			return 0, err
+		}
+	}
+	return changes, nil
+}
+
+// contactID identifies the contact within its course by person id, or by name if it has no id
+func contactID(c contact) string {
+	if c.PersonID != "" {
+		return c.PersonID
+	}
+	return c.FirstName + " " + c.LastName
+}
+
+// contactKey identifies the contact in the changes table
+func contactKey(c contact) string {
+	return fmt.Sprintf("%d/%s", c.CourseID, contactID(c))
+}
+
+// valueOrNil returns v if found and nil otherwise, for recording changes of new entities
+func valueOrNil(v interface{}, found bool) interface{} {
+	if !found {
+		return nil
+	}
+	return v
+}
+
+// Courses returns the stored courses of the semester with their events and contacts, ordered by id
+func (s *Store) Courses(semester campusonline.Semester) ([]campusonline.Course, error) {
+	rows, err := s.db.Query(`SELECT course_id, title, slug FROM courses WHERE semester = ? ORDER BY course_id`,
+		semester.String())
+	if err != nil {
+		return nil, err
+	}
+	var res []campusonline.Course
+	for rows.Next() {
+		var c campusonline.Course
+		if err := rows.Scan(&c.CourseID, &c.Title, &c.Slug); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range res {
+		events, err := queryCourseEvents(s.db, `WHERE course_id = ? ORDER BY start, event_id`, res[i].CourseID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			res[i].Events = append(res[i].Events, campusonline.Event{
+				Title:       e.Title,
+				RoomID:      e.RoomID,
+				Start:       e.Start,
+				End:         e.End,
+				RoomName:    e.RoomName,
+				Comment:     e.Comment,
+				EventID:     e.EventID,
+				LectureFree: e.LectureFree,
+			})
+		}
+		contacts, err := queryContacts(s.db, res[i].CourseID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range contacts {
+			contact := campusonline.ContactPerson{
+				PersonID:    p.PersonID,
+				FirstName:   p.FirstName,
+				LastName:    p.LastName,
+				Email:       p.Email,
+				Role:        p.Role,
+				Roles:       p.Roles,
+				MainContact: p.MainContact,
+			}
+			if p.PersonID != "" {
+				person, found, err := getPerson(s.db, p.PersonID)
+				if err != nil {
+					return nil, err
+				}
+				if found {
+					contact.Address = person.Address
+					contact.Telephone = person.Telephone
+					contact.VisitHours = person.VisitHours
+					contact.WebLink = person.WebLink
+					contact.PictureURL = person.PictureURL
+				}
+			}
+			res[i].Contacts = append(res[i].Contacts, contact)
+		}
+	}
+	return res, nil
+}
+
+func queryCourseEvents(q querier, where string, args ...interface{}) ([]courseEvent, error) {
+	rows, err := q.Query(`SELECT event_id, course_id, title, room_id, room_name, comment, lecture_free, start, end
+		FROM course_events `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []courseEvent
+	for rows.Next() {
+		var e courseEvent
+		var start, end string
+		err := rows.Scan(&e.EventID, &e.CourseID, &e.Title, &e.RoomID, &e.RoomName, &e.Comment, &e.LectureFree, &start, &end)
+		if err != nil {
+			return nil, err
+		}
+		if e.Start, err = parseTime(start); err != nil {
+			return nil, err
+		}
+		if e.End, err = parseTime(end); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
+
+func getCourseEvent(q querier, eventID string) (courseEvent, bool, error) {
+	events, err := queryCourseEvents(q, `WHERE event_id = ?`, eventID)
+	if err != nil || len(events) == 0 {
+		return courseEvent{}, false, err
+	}
+	return events[0], true, nil
+}
+
+func queryContacts(q querier, courseID int) ([]contact, error) {
+	rows, err := q.Query(`SELECT course_id, person_id, first_name, last_name, email, role, roles, main_contact FROM contacts
+		WHERE course_id = ? ORDER BY main_contact DESC, last_name, first_name`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []contact
+	for rows.Next() {
+		var c contact
+		var roles string
+		err := rows.Scan(&c.CourseID, &c.PersonID, &c.FirstName, &c.LastName, &c.Email, &c.Role, &roles, &c.MainContact)
+		if err != nil {
+			return nil, err
+		}
+		c.Roles = parseRoles(roles)
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+func formatRoles(roles []campusonline.Role) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = role.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// normalizeRoles returns nil for empty roles like parseRoles, so contacts without roles compare equal to stored ones
+func normalizeRoles(roles []campusonline.Role) []campusonline.Role {
+	if len(roles) == 0 {
+		return nil
+	}
+	return roles
+}
+
+func parseRoles(s string) []campusonline.Role {
+	if s == "" {
+		return nil
+	}
+	var roles []campusonline.Role
+	for _, name := range strings.Split(s, ",") {
+		roles = append(roles, campusonline.ParseRole(name))
+	}
+	return roles
+}
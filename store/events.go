@@ -0,0 +1,175 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+)
+
+// Event is an event of an organisation's calendar as stored by SaveCalendar
+type Event struct {
+	UID      string    `json:"uid"`
+	OrgID    int       `json:"org_id"`
+	CourseID int       `json:"course_id"` // 0 if the event doesn't belong to a course
+	Summary  string    `json:"summary"`
+	Location string    `json:"location"`
+	Comment  string    `json:"comment"`
+	Status   string    `json:"status"`
+	URL      string    `json:"url"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+}
+
+// eventOf converts an xCal event. ok is false if its times can't be parsed.
+func eventOf(orgID int, e campusonline.VEvent) (Event, bool) {
+	start, err := time.ParseInLocation("20060102T150405", e.Dtstart, time.Local)
+	if err != nil {
+		return Event{}, false
+	}
+	end, err := time.ParseInLocation("20060102T150405", e.Dtend, time.Local)
+	if err != nil {
+		return Event{}, false
+	}
+	courseID := 0
+	if split := strings.Split(e.Description.Altrep, "course/"); len(split) == 2 {
+		courseID, _ = strconv.Atoi(split[1])
+	}
+	return Event{
+		UID:      e.Uid,
+		OrgID:    orgID,
+		CourseID: courseID,
+		Summary:  e.Summary,
+		Location: e.Location.Text,
+		Comment:  e.Comment,
+		Status:   e.Status,
+		URL:      e.Description.Altrep,
+		Start:    start,
+		End:      end,
+	}, true
+}
+
+// SaveCalendar stores the calendar of the organisation fetched for the days from..until. Events are upserted by
+// organisation and uid, so calendars of organisations and their sub-organisations sharing events don't interfere.
+// Stored events of the organisation in that range that are missing from cal are deleted, so cal must be complete and
+// not filtered. It returns the number of recorded changes.
+func (s *Store) SaveCalendar(orgID int, from time.Time, until time.Time, cal campusonline.ICalendar) (int, error) {
+	now := s.now()
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, until.Location()).AddDate(0, 0, 1)
+	changes := 0
+	err := s.tx(func(tx *sql.Tx) error {
+		stored, err := queryEvents(tx, `SELECT `+eventColumns+` FROM events WHERE org_id = ? AND start >= ? AND start < ?`,
+			orgID, formatTime(from), formatTime(until))
+		if err != nil {
+			return err
+		}
+		old := map[string]Event{}
+		for _, e := range stored {
+			old[e.UID] = e
+		}
+		for _, ve := range cal.Vcalendar.Events {
+			e, ok := eventOf(orgID, ve)
+			if !ok {
+				continue
+			}
+			prev, found := old[e.UID]
+			delete(old, e.UID)
+			if !found {
+				// the event may have moved into the range
+				if prev, found, err = getEvent(tx, orgID, e.UID); err != nil {
+					return err
+				}
+			}
+			var prevValue interface{}
+			if found {
+				prevValue = prev
+			}
+			changed, err := recordChange(tx, now, "event", eventChangeKey(e), prevValue, e)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+			changes++
+			_, err = tx.Exec(`INSERT INTO events (uid, org_id, course_id, summary, location, comment, status, url, start, end)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (org_id, uid) DO UPDATE SET course_id = excluded.course_id,
+					summary = excluded.summary, location = excluded.location, comment = excluded.comment,
+					status = excluded.status, url = excluded.url, start = excluded.start, end = excluded.end`,
+				e.UID, e.OrgID, e.CourseID, e.Summary, e.Location, e.Comment, e.Status, e.URL,
+				formatTime(e.Start), formatTime(e.End))
+			if err != nil {
+				return err
+			}
+		}
+		for uid, e := range old {
+			if _, err := recordChange(tx, now, "event", eventChangeKey(e), e, nil); err != nil {
+				return err
+			}
+			changes++
+			if _, err := tx.Exec(`DELETE FROM events WHERE org_id = ? AND uid = ?`, orgID, uid); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return changes, err
+}
+
+// Events returns the stored events of the organisation starting within the days from..until, ordered by start
+func (s *Store) Events(orgID int, from time.Time, until time.Time) ([]Event, error) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, until.Location()).AddDate(0, 0, 1)
+	return queryEvents(s.db, `SELECT `+eventColumns+` FROM events WHERE org_id = ? AND start >= ? AND start < ?
+		ORDER BY start, uid`, orgID, formatTime(from), formatTime(until))
+}
+
+const eventColumns = `uid, org_id, course_id, summary, location, comment, status, url, start, end`
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func queryEvents(q querier, query string, args ...interface{}) ([]Event, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Event
+	for rows.Next() {
+		var e Event
+		var start, end string
+		err := rows.Scan(&e.UID, &e.OrgID, &e.CourseID, &e.Summary, &e.Location, &e.Comment, &e.Status, &e.URL, &start, &end)
+		if err != nil {
+			return nil, err
+		}
+		if e.Start, err = parseTime(start); err != nil {
+			return nil, err
+		}
+		if e.End, err = parseTime(end); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
+
+// eventChangeKey identifies the event in the changes table
+func eventChangeKey(e Event) string {
+	return fmt.Sprintf("%d/%s", e.OrgID, e.UID)
+}
+
+func getEvent(q querier, orgID int, uid string) (Event, bool, error) {
+	events, err := queryEvents(q, `SELECT `+eventColumns+` FROM events WHERE org_id = ? AND uid = ?`, orgID, uid)
+	if err != nil || len(events) == 0 {
+		return Event{}, false, err
+	}
+	return events[0], true, nil
+}
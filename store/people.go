@@ -0,0 +1,125 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+)
+
+// person is a row of the people table, its json is recorded in the changes table
+type person struct {
+	PersonID   string               `json:"person_id"`
+	FirstName  string               `json:"first_name"`
+	LastName   string               `json:"last_name"`
+	Email      string               `json:"email"`
+	Address    campusonline.Address `json:"address"`
+	Telephone  string               `json:"telephone"`
+	VisitHours string               `json:"visit_hours"`
+	WebLink    string               `json:"web_link"`
+	PictureURL string               `json:"picture_url"`
+}
+
+// savePerson upserts the person of the contact and reports whether it changed
+func savePerson(tx *sql.Tx, now time.Time, c campusonline.ContactPerson) (bool, error) {
+	row := person{
+		PersonID:   c.PersonID,
+		FirstName:  c.FirstName,
+		LastName:   c.LastName,
+		Email:      c.Email,
+		Address:    c.Address,
+		Telephone:  c.Telephone,
+		VisitHours: c.VisitHours,
+		WebLink:    c.WebLink,
+		PictureURL: c.PictureURL,
+	}
+	prev, found, err := getPerson(tx, c.PersonID)
+	if err != nil {
+		return false, err
+	}
+	changed, err := recordChange(tx, now, "person", c.PersonID, valueOrNil(prev, found), row)
+	if err != nil || !changed {
+		return false, err
+	}
+	_, err = tx.Exec(`INSERT INTO people (person_id, first_name, last_name, email, extra, street, locality, postal_code,
+			country, telephone, visit_hours, web_link, picture_url)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (person_id) DO UPDATE SET first_name = excluded.first_name, last_name = excluded.last_name,
+			email = excluded.email, extra = excluded.extra, street = excluded.street, locality = excluded.locality,
+			postal_code = excluded.postal_code, country = excluded.country, telephone = excluded.telephone,
+			visit_hours = excluded.visit_hours, web_link = excluded.web_link, picture_url = excluded.picture_url`,
+		row.PersonID, row.FirstName, row.LastName, row.Email, row.Address.Extra, row.Address.Street,
+		row.Address.Locality, row.Address.PostalCode, row.Address.Country, row.Telephone, row.VisitHours, row.WebLink,
+		row.PictureURL)
+	return err == nil, err
+}
+
+func queryPeople(q querier, where string, args ...interface{}) ([]person, error) {
+	rows, err := q.Query(`SELECT person_id, first_name, last_name, email, extra, street, locality, postal_code, country,
+		telephone, visit_hours, web_link, picture_url FROM people `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []person
+	for rows.Next() {
+		var p person
+		err := rows.Scan(&p.PersonID, &p.FirstName, &p.LastName, &p.Email, &p.Address.Extra, &p.Address.Street,
+			&p.Address.Locality, &p.Address.PostalCode, &p.Address.Country, &p.Telephone, &p.VisitHours, &p.WebLink,
+			&p.PictureURL)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+func getPerson(q querier, personID string) (person, bool, error) {
+	people, err := queryPeople(q, `WHERE person_id = ?`, personID)
+	if err != nil || len(people) == 0 {
+		return person{}, false, err
+	}
+	return people[0], true, nil
+}
+
+// People returns everybody stored as contact of a course, sorted by name, with the ids of their courses
+func (s *Store) People() ([]campusonline.Person, error) {
+	people, err := queryPeople(s.db, `ORDER BY last_name, first_name, person_id`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT person_id, course_id FROM contacts WHERE person_id != '' ORDER BY course_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	courses := map[string][]int{}
+	for rows.Next() {
+		var personID string
+		var courseID int
+		if err := rows.Scan(&personID, &courseID); err != nil {
+			return nil, err
+		}
+		courses[personID] = append(courses[personID], courseID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	res := make([]campusonline.Person, len(people))
+	for i, p := range people {
+		res[i] = campusonline.Person{
+			PersonID:   p.PersonID,
+			FirstName:  p.FirstName,
+			LastName:   p.LastName,
+			Email:      p.Email,
+			Address:    p.Address,
+			Telephone:  p.Telephone,
+			VisitHours: p.VisitHours,
+			WebLink:    p.WebLink,
+			PictureURL: p.PictureURL,
+			CourseIDs:  courses[p.PersonID],
+		}
+	}
+	return res, nil
+}
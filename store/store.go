@@ -0,0 +1,248 @@
+// Package store persists data fetched from TUMonline in SQLite, so past semesters can be queried and analysed
+// without fetching them again. Every insert, update and deletion is recorded in the changes table.
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 driver
+)
+
+// migrations are applied in order. The number of applied migrations is stored as the database's user_version.
+// Never edit a migration that was released, add a new one instead.
+var migrations = []string{
+	// events of sub-organisations also appear in the calendars of their parents, so events are stored per organisation
+	`CREATE TABLE events (
+		uid       TEXT NOT NULL,
+		org_id    INTEGER NOT NULL,
+		course_id INTEGER NOT NULL, -- 0 for events that don't belong to a course
+		summary   TEXT NOT NULL,
+		location  TEXT NOT NULL,
+		comment   TEXT NOT NULL,
+		status    TEXT NOT NULL,
+		url       TEXT NOT NULL,
+		start     TEXT NOT NULL, -- RFC 3339 in UTC, so it sorts and compares as text
+		end       TEXT NOT NULL,
+		PRIMARY KEY (org_id, uid)
+	);
+	CREATE INDEX events_org_start ON events (org_id, start);
+	CREATE TABLE courses (
+		course_id INTEGER PRIMARY KEY,
+		title     TEXT NOT NULL,
+		slug      TEXT NOT NULL,
+		semester  TEXT NOT NULL -- e.g. 2024W
+	);
+	CREATE INDEX courses_semester ON courses (semester);
+	CREATE TABLE course_events (
+		event_id     TEXT PRIMARY KEY,
+		course_id    INTEGER NOT NULL REFERENCES courses (course_id),
+		title        TEXT NOT NULL,
+		room_id      INTEGER NOT NULL,
+		room_name    TEXT NOT NULL,
+		comment      TEXT NOT NULL,
+		lecture_free INTEGER NOT NULL,
+		start        TEXT NOT NULL,
+		end          TEXT NOT NULL
+	);
+	CREATE INDEX course_events_course ON course_events (course_id, start);
+	CREATE TABLE contacts (
+		course_id    INTEGER NOT NULL REFERENCES courses (course_id),
+		contact_key  TEXT NOT NULL, -- the person id, or first and last name if the contact has no id
+		person_id    TEXT NOT NULL,
+		first_name   TEXT NOT NULL,
+		last_name    TEXT NOT NULL,
+		email        TEXT NOT NULL,
+		role         TEXT NOT NULL,
+		roles        TEXT NOT NULL, -- comma separated, e.g. lecturer,examiner
+		main_contact INTEGER NOT NULL,
+		PRIMARY KEY (course_id, contact_key)
+	);
+	CREATE INDEX contacts_person ON contacts (person_id);
+	CREATE TABLE people (
+		person_id   TEXT PRIMARY KEY,
+		first_name  TEXT NOT NULL,
+		last_name   TEXT NOT NULL,
+		email       TEXT NOT NULL,
+		extra       TEXT NOT NULL, -- first line of the address, usually the room
+		street      TEXT NOT NULL,
+		locality    TEXT NOT NULL,
+		postal_code TEXT NOT NULL,
+		country     TEXT NOT NULL,
+		telephone   TEXT NOT NULL,
+		visit_hours TEXT NOT NULL,
+		web_link    TEXT NOT NULL,
+		picture_url TEXT NOT NULL
+	);
+	CREATE TABLE changes (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		entity     TEXT NOT NULL, -- event, course, course_event, contact or person
+		key        TEXT NOT NULL,
+		kind       TEXT NOT NULL, -- created, updated or deleted
+		old        TEXT,          -- json of the entity before the change
+		new        TEXT,          -- json of the entity after the change
+		changed_at TEXT NOT NULL
+	);
+	CREATE INDEX changes_changed_at ON changes (changed_at);`,
+}
+
+const timeFormat = time.RFC3339
+
+// Store is a SQLite database of TUMonline data
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// Open opens the SQLite database at path, creating it if necessary, and migrates it to the current schema
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// New returns a store backed by db, which must be a SQLite database, and migrates it to the current schema
+func New(db *sql.DB) (*Store, error) {
+	s := &Store{db: db, now: time.Now}
+	if err := s.migrate(); err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	return s, nil
+}
+
+// DB returns the underlying database for queries the store doesn't provide
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this package (%d)", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		err := s.tx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(migrations[i]); err != nil {
+				return err
+			}
+			_, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) tx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Change is an entry of the history of changes
+type Change struct {
+	ID     int64
+	Entity string // event, course, course_event, contact or person
+	Key    string
+	Kind   string          // created, updated or deleted
+	Old    json.RawMessage // nil if the entity was created
+	New    json.RawMessage // nil if the entity was deleted
+	At     time.Time
+}
+
+// recordChange stores the change from old to new, either of which may be nil. Nothing is recorded if they're equal.
+func recordChange(tx *sql.Tx, at time.Time, entity string, key string, old interface{}, new interface{}) (bool, error) {
+	var oldJSON, newJSON []byte
+	var err error
+	kind := "updated"
+	if old == nil {
+		kind = "created"
+	} else if oldJSON, err = json.Marshal(old); err != nil {
+		return false, err
+	}
+	if new == nil {
+		kind = "deleted"
+	} else if newJSON, err = json.Marshal(new); err != nil {
+		return false, err
+	}
+	if string(oldJSON) == string(newJSON) {
+		return false, nil
+	}
+	_, err = tx.Exec(`INSERT INTO changes (entity, key, kind, old, new, changed_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		entity, key, kind, nullJSON(oldJSON), nullJSON(newJSON), formatTime(at))
+	return err == nil, err
+}
+
+func nullJSON(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
+
+// Changes returns the changes recorded since the given time, oldest first
+func (s *Store) Changes(since time.Time) ([]Change, error) {
+	rows, err := s.db.Query(`SELECT id, entity, key, kind, old, new, changed_at FROM changes
+		WHERE changed_at >= ? ORDER BY id`, formatTime(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Change
+	for rows.Next() {
+		var c Change
+		var old, new sql.NullString
+		var at string
+		if err := rows.Scan(&c.ID, &c.Entity, &c.Key, &c.Kind, &old, &new, &at); err != nil {
+			return nil, err
+		}
+		if old.Valid {
+			c.Old = json.RawMessage(old.String)
+		}
+		if new.Valid {
+			c.New = json.RawMessage(new.String)
+		}
+		if c.At, err = time.Parse(timeFormat, at); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(timeFormat, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Local(), nil
+}
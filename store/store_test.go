@@ -0,0 +1,349 @@
+package store
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	campusonline "github.com/RBG-TUM/CAMPUSOnline"
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+var (
+	semester      = campusonline.Semester{Year: 2021, Term: campusonline.Winter}
+	semesterStart = semester.Start()
+	semesterEnd   = semester.End()
+)
+
+// newTestStore returns a store whose clock is advanced by setting *now
+func newTestStore(t *testing.T) (*Store, *time.Time) {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "campusonline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	now := time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+// fixtureCalendar returns the calendar of the computer science fixture
+func fixtureCalendar(t *testing.T) campusonline.ICalendar {
+	t.Helper()
+	server := campusonlinetest.NewServer()
+	defer server.Close()
+	c, err := campusonline.New(campusonlinetest.Token, campusonlinetest.BasicToken,
+		campusonline.WithBaseURL(server.BaseURL(), server.BasicBaseURL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal, err := c.GetXCalOrg(semesterStart, semesterEnd, campusonline.CsOrgId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cal
+}
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "campusonline.db")
+	for i := 0; i < 2; i++ { // migrating again must be a no-op
+		s, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var version int
+		if err := s.DB().QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		if version != len(migrations) {
+			t.Errorf("expected schema version %d, got %d", len(migrations), version)
+		}
+		s.Close()
+	}
+}
+
+func TestSaveCalendar(t *testing.T) {
+	s, now := newTestStore(t)
+	cal := fixtureCalendar(t)
+	changes, err := s.SaveCalendar(campusonline.CsOrgId, semesterStart, semesterEnd, cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes != 20 {
+		t.Errorf("expected 20 created events, got %d", changes)
+	}
+	if changes, _ := s.SaveCalendar(campusonline.CsOrgId, semesterStart, semesterEnd, cal); changes != 0 {
+		t.Errorf("expected saving the same calendar to change nothing, got %d changes", changes)
+	}
+
+	events, err := s.Events(campusonline.CsOrgId, semesterStart, semesterEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 20 || events[0].Start.After(events[1].Start) {
+		t.Fatalf("expected 20 sorted events, got %d", len(events))
+	}
+	e := events[0]
+	if e.CourseID == 0 || e.Summary == "" || e.Start.IsZero() {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	// move the first event and drop the last one
+	*now = now.Add(time.Hour)
+	moved := cal.Vcalendar.Events[0]
+	moved.Location.Text = "somewhere else"
+	cal.Vcalendar.Events = append(campusonline.Events{moved}, cal.Vcalendar.Events[1:len(cal.Vcalendar.Events)-1]...)
+	changes, err = s.SaveCalendar(campusonline.CsOrgId, semesterStart, semesterEnd, cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes != 2 {
+		t.Errorf("expected an update and a deletion, got %d changes", changes)
+	}
+	history, err := s.Changes(*now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Kind != "updated" || history[1].Kind != "deleted" || history[1].New != nil {
+		t.Fatalf("unexpected changes %+v", history)
+	}
+	if !strings.Contains(string(history[0].New), "somewhere else") || strings.Contains(string(history[0].Old), "somewhere else") {
+		t.Errorf("expected old and new event in the change, got %s -> %s", history[0].Old, history[0].New)
+	}
+
+	// the parent organisation's calendar contains the same events
+	const parentOrgID = 1
+	if changes, err = s.SaveCalendar(parentOrgID, semesterStart, semesterEnd, cal); err != nil || changes != 19 {
+		t.Errorf("expected 19 events created for the parent, got %d changes and error %v", changes, err)
+	}
+	if changes, _ = s.SaveCalendar(campusonline.CsOrgId, semesterStart, semesterEnd, cal); changes != 0 {
+		t.Errorf("expected saving the sub-organisation again to change nothing, got %d changes", changes)
+	}
+	if events, _ := s.Events(campusonline.CsOrgId, semesterStart, semesterEnd); len(events) != 19 {
+		t.Errorf("expected the sub-organisation to keep its 19 events, got %d", len(events))
+	}
+}
+
+func TestSaveCourses(t *testing.T) {
+	s, now := newTestStore(t)
+	cal := fixtureCalendar(t)
+	cal.Filter()
+	courses := cal.GroupByCourse()
+	for i := range courses {
+		if courses[i].CourseID == 950000001 {
+			courses[i].Contacts = []campusonline.ContactPerson{
+				{FirstName: "Helmut", LastName: "Seidl", Role: "Leiter/in", MainContact: true,
+					Roles: []campusonline.Role{campusonline.RoleLecturer, campusonline.RoleExaminer}},
+				{FirstName: "Michael", LastName: "Petter", Role: "Mitwirkende/r"},
+			}
+		}
+	}
+	if _, err := s.SaveCourses(semester, courses); err != nil {
+		t.Fatal(err)
+	}
+	if changes, _ := s.SaveCourses(semester, courses); changes != 0 {
+		t.Errorf("expected saving the same courses to change nothing, got %d changes", changes)
+	}
+
+	stored, err := s.Courses(semester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != len(courses) {
+		t.Fatalf("expected %d courses, got %d", len(courses), len(stored))
+	}
+	c := stored[0]
+	if c.CourseID != 950000001 || c.Slug == "" || len(c.Events) != 6 || len(c.Contacts) != 2 || !c.Contacts[0].MainContact {
+		t.Errorf("unexpected course %+v", c)
+	}
+	if seidl := c.Contacts[0]; !seidl.HasRole(campusonline.RoleExaminer) || len(seidl.Roles) != 2 {
+		t.Errorf("expected roles to be stored, got %v", seidl.Roles)
+	}
+	for _, e := range c.Events {
+		if e.EventID == "" {
+			t.Errorf("expected event ids, got %+v", e)
+		}
+	}
+	if other, _ := s.Courses(semester.Next()); len(other) != 0 {
+		t.Errorf("expected no courses in the next semester, got %d", len(other))
+	}
+
+	// contacts are replaced
+	*now = now.Add(time.Hour)
+	for i := range courses {
+		if courses[i].CourseID == 950000001 {
+			courses[i].Contacts = courses[i].Contacts[:1]
+			courses[i].Contacts[0].Email = "seidl@in.tum.de"
+		}
+	}
+	changes, err := s.SaveCourses(semester, courses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes != 2 {
+		t.Errorf("expected an updated and a deleted contact, got %d changes", changes)
+	}
+	history, _ := s.Changes(*now)
+	for _, change := range history {
+		if change.Entity != "contact" {
+			t.Errorf("unexpected change %+v", change)
+		}
+	}
+
+	// events that vanished from a course are deleted
+	*now = now.Add(time.Hour)
+	var cancelled campusonline.Event
+	for i := range courses {
+		if courses[i].CourseID == 950000001 {
+			cancelled = courses[i].Events[0]
+			courses[i].Events = courses[i].Events[1:]
+		}
+	}
+	if changes, err = s.SaveCourses(semester, courses); err != nil || changes != 1 {
+		t.Errorf("expected a deleted event, got %d changes and error %v", changes, err)
+	}
+	history, _ = s.Changes(*now)
+	if len(history) != 1 || history[0].Entity != "course_event" || history[0].Key != cancelled.EventID ||
+		history[0].Kind != "deleted" {
+		t.Errorf("expected the deletion of event %s to be recorded, got %+v", cancelled.EventID, history)
+	}
+	if stored, _ = s.Courses(semester); len(stored[0].Events) != 5 {
+		t.Errorf("expected 5 remaining events, got %d", len(stored[0].Events))
+	}
+}
+
+func TestSaveCoursesRanges(t *testing.T) {
+	s, _ := newTestStore(t)
+	cal := fixtureCalendar(t)
+	courses := cal.GroupByCourse()
+	split := time.Date(2021, 11, 1, 0, 0, 0, 0, time.Local)
+	// inRange returns the courses with their events starting within from..until
+	inRange := func(from time.Time, until time.Time) []campusonline.Course {
+		var res []campusonline.Course
+		for _, c := range courses {
+			var events []campusonline.Event
+			for _, e := range c.Events {
+				if !e.Start.Before(from) && e.Start.Before(until) {
+					events = append(events, e)
+				}
+			}
+			if len(events) != 0 {
+				c.Events = events
+				res = append(res, c)
+			}
+		}
+		return res
+	}
+	first, second := inRange(semesterStart, split), inRange(split, semesterEnd)
+	if len(first) == 0 || len(second) == 0 {
+		t.Fatal("expected events in both ranges")
+	}
+	if _, err := s.SaveCoursesRange(semester, semesterStart, split.AddDate(0, 0, -1), first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveCoursesRange(semester, split, semesterEnd, second); err != nil {
+		t.Fatal(err)
+	}
+	history, _ := s.Changes(time.Time{})
+	for _, change := range history {
+		if change.Kind == "deleted" {
+			t.Errorf("expected no deletions, got %+v", change)
+		}
+	}
+	stored, err := s.Courses(semester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, expected := 0, 0
+	for i := range stored {
+		total += len(stored[i].Events)
+	}
+	for _, c := range courses {
+		expected += len(c.Events)
+	}
+	if total != expected {
+		t.Errorf("expected the events of both ranges, got %d of %d", total, expected)
+	}
+}
+
+func TestContactKeys(t *testing.T) {
+	s, now := newTestStore(t)
+	// two people of the same name
+	first := campusonline.ContactPerson{PersonID: "AAAAAAAAAAAA", FirstName: "Anna", LastName: "Müller"}
+	second := campusonline.ContactPerson{PersonID: "BBBBBBBBBBBB", FirstName: "Anna", LastName: "Müller"}
+	courses := []campusonline.Course{{CourseID: 950000001, Title: "EidI", Contacts: []campusonline.ContactPerson{first, second}}}
+	if _, err := s.SaveCourses(semester, courses); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := s.Courses(semester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored[0].Contacts) != 2 {
+		t.Fatalf("expected both people, got %+v", stored[0].Contacts)
+	}
+
+	// renaming a person updates the contact
+	*now = now.Add(time.Hour)
+	courses[0].Contacts[0].LastName = "Schmidt"
+	if _, err := s.SaveCourses(semester, courses); err != nil {
+		t.Fatal(err)
+	}
+	history, _ := s.Changes(*now)
+	var contactChanges []Change
+	for _, change := range history {
+		if change.Entity == "contact" {
+			contactChanges = append(contactChanges, change)
+		}
+	}
+	if len(contactChanges) != 1 || contactChanges[0].Kind != "updated" || contactChanges[0].Key != "950000001/AAAAAAAAAAAA" {
+		t.Errorf("expected the contact to be updated, got %+v", contactChanges)
+	}
+}
+
+func TestContactWithoutRoles(t *testing.T) {
+	s, now := newTestStore(t)
+	tutor := campusonline.ContactPerson{PersonID: "ABCABCABCABC", FirstName: "Anna", LastName: "Tutorin", Roles: []campusonline.Role{}}
+	courses := []campusonline.Course{{CourseID: 950000001, Title: "EidI", Contacts: []campusonline.ContactPerson{tutor}}}
+	if _, err := s.SaveCourses(semester, courses); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(time.Hour)
+	if changes, err := s.SaveCourses(semester, courses); err != nil || changes != 0 {
+		t.Errorf("expected saving the same contact again to change nothing, got %d changes (%v)", changes, err)
+	}
+}
+
+func TestPeople(t *testing.T) {
+	s, _ := newTestStore(t)
+	seidl := campusonline.ContactPerson{PersonID: "A1B2C3D4E5F6", FirstName: "Helmut", LastName: "Seidl",
+		Telephone: "+49 89 289 17000", Address: campusonline.Address{Extra: "Raum 02.07.042", PostalCode: "85748"}}
+	tutor := campusonline.ContactPerson{PersonID: "ABCABCABCABC", FirstName: "Anna", LastName: "Tutorin"}
+	courses := []campusonline.Course{
+		{CourseID: 950000001, Title: "EidI", Contacts: []campusonline.ContactPerson{seidl}},
+		{CourseID: 950000004, Title: "EidI Übung", Contacts: []campusonline.ContactPerson{tutor, seidl}},
+	}
+	changes, err := s.SaveCourses(semester, courses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes != 2+3+2 { // courses, contacts and people
+		t.Errorf("expected 7 changes, got %d", changes)
+	}
+	people, err := s.People()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(people) != 2 || people[0].LastName != "Seidl" || len(people[0].CourseIDs) != 2 || people[0].Address != seidl.Address {
+		t.Fatalf("unexpected people %+v", people)
+	}
+	stored, err := s.Courses(semester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contact := stored[0].Contacts[0]; contact.PersonID != seidl.PersonID || contact.Telephone != seidl.Telephone {
+		t.Errorf("expected contact details from the people table, got %+v", contact)
+	}
+}
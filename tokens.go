@@ -0,0 +1,150 @@
+package campusonline
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken is returned when TUMonline rejects the token of a request
+var ErrInvalidToken = errors.New("TUMonline rejected the token")
+
+// TokenProvider supplies the token for a request. It is consulted for every request, so tokens can be rotated
+// without creating a new client.
+type TokenProvider interface {
+	Token() (string, error)
+}
+
+// TokenRefresher is implemented by token providers that can be forced to fetch a new token. The client calls
+// RefreshToken when TUMonline rejects a token and retries the request if the token changed.
+type TokenRefresher interface {
+	RefreshToken() error
+}
+
+// WithTokenProvider makes the client take tokens for the webservice_v1.0 api from p instead of the token passed to New
+func WithTokenProvider(p TokenProvider) Option {
+	return func(c *CampusOnline) {
+		c.tokens = p
+	}
+}
+
+// WithBasicTokenProvider makes the client take tokens for the wbservicesbasic api from p instead of the basic token
+// passed to New
+func WithBasicTokenProvider(p TokenProvider) Option {
+	return func(c *CampusOnline) {
+		c.basicTokens = p
+	}
+}
+
+// StaticToken is a token that never changes
+type StaticToken string
+
+func (t StaticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+// EnvToken reads the token from the environment variable with its name on every request
+type EnvToken string
+
+func (e EnvToken) Token() (string, error) {
+	token := strings.TrimSpace(os.Getenv(string(e)))
+	if token == "" {
+		return "", fmt.Errorf("environment variable %s is not set", string(e))
+	}
+	return token, nil
+}
+
+// TokenFunc turns a function into a TokenProvider
+type TokenFunc func() (string, error)
+
+func (f TokenFunc) Token() (string, error) {
+	return f()
+}
+
+// FileToken reads the token from a file and reads it again whenever the file changes
+type FileToken struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileToken returns a provider for the token stored in the file at path. Surrounding whitespace is ignored.
+func NewFileToken(path string) *FileToken {
+	return &FileToken{path: path}
+}
+
+func (f *FileToken) Token() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.token != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.token, nil
+	}
+	return f.read()
+}
+
+// RefreshToken reads the file again even if it seems unchanged
+func (f *FileToken) RefreshToken() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err := f.read()
+	return err
+}
+
+// read must be called with f.mu held
+func (f *FileToken) read() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", f.path)
+	}
+	f.token, f.modTime, f.size = token, info.ModTime(), info.Size()
+	return token, nil
+}
+
+// currentToken returns the current token for the api and remembers it for redaction
+func (c *CampusOnline) currentToken(basic bool) (string, error) {
+	p := c.tokens
+	if basic {
+		p = c.basicTokens
+	}
+	token, err := p.Token()
+	if err != nil {
+		return "", fmt.Errorf("get token: %w", err)
+	}
+	if token != "" {
+		c.knownTokens.Store(token, true)
+	}
+	return token, nil
+}
+
+// refreshToken asks the provider of the api for a new token and returns it
+func (c *CampusOnline) refreshToken(basic bool) (string, error) {
+	p := c.tokens
+	if basic {
+		p = c.basicTokens
+	}
+	if refresher, ok := p.(TokenRefresher); ok {
+		if err := refresher.RefreshToken(); err != nil {
+			return "", fmt.Errorf("refresh token: %w", err)
+		}
+	}
+	return c.currentToken(basic)
+}
@@ -0,0 +1,105 @@
+package campusonline
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+func TestInvalidToken(t *testing.T) {
+	c, server := newTestClient(t)
+	server.SetTokens("rotated", "rotated")
+	_, err := c.GetOrganisations()
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("static tokens must not be retried, got %d requests", got)
+	}
+}
+
+func TestInvalidTokenDocument(t *testing.T) {
+	c, server := newTestClient(t)
+	server.SetCourse(1, []byte(`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<error><message>Token ungültig</message></error>`))
+	_, err := c.exportCourseByID(1)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestErrorDocument(t *testing.T) {
+	// a provider that would hand out a new token if the request was retried
+	calls := 0
+	provider := TokenFunc(func() (string, error) {
+		calls++
+		if calls == 1 {
+			return campusonlinetest.Token, nil
+		}
+		return "rotated", nil
+	})
+	c, server := newTestClient(t, WithTokenProvider(provider))
+	server.SetCourse(1, []byte(`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<error><message>Parameter courseID fehlt</message></error>`))
+	_, err := c.exportCourseByID(1)
+	var docErr *DocumentError
+	if !errors.As(err, &docErr) || docErr.Message != "Parameter courseID fehlt" {
+		t.Errorf("expected error document with its message, got %v", err)
+	}
+	if errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected error unrelated to the token, got %v", err)
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("expected errors unrelated to the token not to be retried, got %d requests", got)
+	}
+}
+
+func TestTokenRefreshRetry(t *testing.T) {
+	calls := 0
+	provider := TokenFunc(func() (string, error) {
+		calls++
+		if calls == 1 {
+			return campusonlinetest.Token, nil
+		}
+		return "rotated", nil
+	})
+	c, server := newTestClient(t, WithTokenProvider(provider))
+	server.SetTokens("rotated", campusonlinetest.BasicToken)
+	if _, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(server.Requests()); got != 2 {
+		t.Errorf("expected the request to be retried once, got %d requests", got)
+	}
+}
+
+func TestFileToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(path, []byte(campusonlinetest.BasicToken+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, server := newTestClient(t, WithBasicTokenProvider(NewFileToken(path)))
+	if _, err := c.SearchCourses("Informatik", Semester{2021, Winter}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetTokens(campusonlinetest.Token, "rotated")
+	if err := ioutil.WriteFile(path, []byte("rotated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SearchCourses("Informatik", Semester{2021, Winter}); err != nil {
+		t.Errorf("expected rotated token to be picked up, got %v", err)
+	}
+}
+
+func TestEnvToken(t *testing.T) {
+	t.Setenv("CAMPUSONLINE_TEST_TOKEN", "")
+	if _, err := EnvToken("CAMPUSONLINE_TEST_TOKEN").Token(); err == nil {
+		t.Error("expected error for unset variable")
+	}
+	t.Setenv("CAMPUSONLINE_TEST_TOKEN", campusonlinetest.Token)
+	c, _ := newTestClient(t, WithTokenProvider(EnvToken("CAMPUSONLINE_TEST_TOKEN")))
+	if _, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,10 +1,7 @@
 package campusonline
 
 import (
-	"encoding/xml"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"regexp"
 	"sort"
 	"strconv"
@@ -39,29 +36,92 @@ func (c *CampusOnline) GetXCalPh(from time.Time, until time.Time) (ICalendar, er
 	return c.GetXCalOrg(from, until, PhOrgID)
 }
 
+// GetXCalOrg returns all events in the specified time stamp for the organization.
+// Long ranges are fetched in parallel chunks if the client is configured with WithChunkSize.
 func (c *CampusOnline) GetXCalOrg(from time.Time, until time.Time, orgID int) (ICalendar, error) {
-	url := baseURL + fmt.Sprintf(xCalOrgDN, c.token, orgID, from.Format("20060102"), until.Format("20060102"))
-	println(url)
-	resp, err := http.Get(url)
-	if err != nil {
-		return ICalendar{}, err
+	chunks := dateChunks(from, until, c.chunkSize)
+	if len(chunks) == 1 {
+		return c.getXCalOrgChunk(chunks[0].from, chunks[0].until, orgID)
 	}
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return ICalendar{}, err
+	cals := make([]ICalendar, len(chunks))
+	errs := c.parallel(len(chunks), func(i int) error {
+		var err error
+		cals[i], err = c.getXCalOrgChunk(chunks[i].from, chunks[i].until, orgID)
+		return err
+	})
+	for _, err := range errs {
+		if err != nil {
+			return ICalendar{}, err
+		}
+	}
+	return mergeCalendars(cals...), nil
+}
+
+type dateRange struct {
+	from  time.Time
+	until time.Time
+}
+
+// dateChunks splits the days from..until into consecutive ranges of at most size.
+// TUMonline only considers the date, so ranges are aligned to whole days and never overlap.
+func dateChunks(from time.Time, until time.Time, size time.Duration) []dateRange {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, until.Location())
+	if size <= 0 || until.Before(from) {
+		return []dateRange{{from: from, until: until}}
+	}
+	days := int(size / (time.Hour * 24))
+	if days < 1 {
+		days = 1
+	}
+	var res []dateRange
+	for start := from; !start.After(until); start = start.AddDate(0, 0, days) {
+		end := start.AddDate(0, 0, days-1)
+		if end.After(until) {
+			end = until
+		}
+		res = append(res, dateRange{from: start, until: end})
 	}
+	return res
+}
+
+func (c *CampusOnline) getXCalOrgChunk(from time.Time, until time.Time, orgID int) (ICalendar, error) {
 	var res ICalendar
-	err = xml.Unmarshal(body, &res)
+	err := c.getXML(xCalOrgURL(from, until, orgID), &res)
 	if err != nil {
 		return ICalendar{}, err
 	}
 	return res, nil
 }
 
+func xCalOrgURL(from time.Time, until time.Time, orgID int) apiURL {
+	return wsURL(xCalOrgDN, orgID, from.Format("20060102"), until.Format("20060102"))
+}
+
 func (c *ICalendar) Sort() {
 	sort.Sort(c.Vcalendar.Events)
 }
 
+// mergeCalendars combines the events of all calendars into one, dropping events with duplicate uids
+func mergeCalendars(cals ...ICalendar) ICalendar {
+	if len(cals) == 0 {
+		return ICalendar{}
+	}
+	res := cals[0]
+	res.Vcalendar.Events = nil
+	seen := map[string]bool{}
+	for _, cal := range cals {
+		for _, event := range cal.Vcalendar.Events {
+			if event.Uid != "" && seen[event.Uid] {
+				continue
+			}
+			seen[event.Uid] = true
+			res.Vcalendar.Events = append(res.Vcalendar.Events, event)
+		}
+	}
+	return res
+}
+
 func (c *ICalendar) Filter() {
 	var newEvents []VEvent
 	re, _ := regexp.Compile("^[0-9]+")
@@ -69,9 +129,8 @@ func (c *ICalendar) Filter() {
 		if strings.Contains(event.Summary, "Praktikum Systemadministration") {
 			event.Location.Text = "5620.01.102 (102, Hörsaal 2, \"Interims I\"), Boltzmannstr. 5(5620), 85748 Garching b. München"
 		}
-		if (event.Status == "fix" || event.Status == "geplant") &&
-			inRoomList(event.Location.Text) &&
-			!strings.Contains(strings.ToLower(event.Comment), "videoübertragung aus") {
+		status := icsStatus(event.Status)
+		if (status == "CONFIRMED" || status == "TENTATIVE") && inRoomList(event.Location.Text) && !isTransmission(event.Comment) {
 			// remove prepending digits
 			event.Summary = strings.TrimSpace(re.ReplaceAllString(event.Summary, ""))
 
@@ -89,6 +148,20 @@ func (c *ICalendar) Filter() {
 	c.Vcalendar.Events = newEvents
 }
 
+// transmissionTexts mark events in German and English calendars that are only a video transmission from another
+// lecture hall
+var transmissionTexts = []string{"videoübertragung aus", "video transmission from"}
+
+func isTransmission(comment string) bool {
+	comment = strings.ToLower(comment)
+	for _, text := range transmissionTexts {
+		if strings.Contains(comment, text) {
+			return true
+		}
+	}
+	return false
+}
+
 var roomList = map[string]string{
 	"5602.EG.001":  "MI HS1",
 	"5604.EG.011":  "MI HS2",
@@ -130,6 +203,7 @@ func (c *ICalendar) GroupByCourse() []Course {
 		if parseErr != nil {
 			continue
 		}
+		uid := strings.Split(event.Uid, "@")[0]
 		if !found {
 			cID, err := strconv.Atoi(splitUrl[1])
 			if err != nil {
@@ -158,15 +232,11 @@ func (c *ICalendar) GroupByCourse() []Course {
 					RoomName: event.Location.Text,
 					Comment:  event.Comment,
 					Import:   true,
+					EventID:  uid,
 				}},
 				Contacts: nil,
 			}
 		} else {
-			uidArr := strings.Split(event.Uid, "@")
-			uid := ""
-			if len(uidArr) > 0 {
-				uid = uidArr[0]
-			}
 			foundCourse.Events = append(foundCourse.Events, Event{
 				Title:    "",
 				Start:    start,
@@ -196,47 +266,70 @@ func generateCourseSlug(title string) string {
 	return courseSlug
 }
 
+// LoadCourseContacts adds the contacts of their CDM exports to the courses. Main contacts are picked by the roles
+// configured with WithMainContactRoles. Titles and descriptions are set in the languages configured with
+// WithLanguages, courses without title get the one of the first language.
 func (c CampusOnline) LoadCourseContacts(courses []Course) ([]Course, error) {
+	languages := c.languages
+	if len(languages) == 0 {
+		languages = []Language{German}
+	}
 	for i := range courses {
-		url := baseURL + fmt.Sprintf(courseExportDN, c.token, courses[i].CourseID)
-		got, err := http.Get(url)
-		if err != nil {
-			return nil, err
-		}
-		body, err := ioutil.ReadAll(got.Body)
-		if err != nil {
-			return nil, err
-		}
 		var res CDM
-		err = xml.Unmarshal(body, &res)
-		if err != nil {
-			return nil, err
-		}
-		hasMainContact := false
-		for _, person := range res.Course.Contacts.Person {
-			isMainContact := false
-			pRole := ""
-			for _, r := range person.Role {
-				if pRole != "" {
-					pRole += ", "
-				}
-				pRole += r.Text
+		for j, lang := range languages {
+			export, err := c.exportCourse(courses[i].CourseID, lang)
+			if err != nil {
+				return nil, err
 			}
-			if !hasMainContact && (strings.Contains(strings.ToLower(pRole), "leiter") || strings.Contains(strings.ToLower(pRole), "prüfer")) {
-				isMainContact = true
-				hasMainContact = true
+			if j == 0 {
+				res = export
 			}
-			courses[i].Contacts = append(courses[i].Contacts, ContactPerson{
-				FirstName:   person.Name.Given,
-				LastName:    person.Name.Family,
-				Email:       person.ContactData.Email,
-				Role:        pRole,
-				MainContact: isMainContact,
-			})
+			if courses[i].Titles == nil {
+				courses[i].Titles = LocalizedText{}
+				courses[i].Descriptions = LocalizedText{}
+			}
+			courses[i].Titles[lang] = strings.TrimSpace(export.Course.CourseName.Text)
+			courses[i].Descriptions[lang] = strings.TrimSpace(export.Course.CourseDescription)
 		}
-		if !hasMainContact && len(courses[i].Contacts) != 0 {
-			courses[i].Contacts[0].MainContact = true
+		if courses[i].Title == "" {
+			courses[i].Title = courses[i].Titles.In(languages[0])
 		}
+		courses[i].Contacts = append(courses[i].Contacts, c.ContactsOf(res)...)
 	}
 	return courses, nil
 }
+
+// ContactsOf converts the contacts of a course export. Main contacts are picked like in LoadCourseContacts.
+func (c CampusOnline) ContactsOf(export CDM) []ContactPerson {
+	var res []ContactPerson
+	for _, person := range export.Course.Contacts.Person {
+		var texts []string
+		var roles []Role
+		for _, r := range person.Role {
+			texts = append(texts, r.Text)
+			roles = append(roles, roleOf(r.RoleID, r.Text))
+		}
+		data := person.ContactData
+		res = append(res, ContactPerson{
+			PersonID:  strings.TrimSpace(person.PersonID),
+			FirstName: person.Name.Given,
+			LastName:  person.Name.Family,
+			Email:     data.Email,
+			Role:      strings.Join(texts, ", "),
+			Roles:     roles,
+			Address: Address{
+				Extra:      data.Adr.Extadr,
+				Street:     data.Adr.Street,
+				Locality:   data.Adr.Locality,
+				PostalCode: data.Adr.Pcode,
+				Country:    data.Adr.Country,
+			},
+			Telephone:  strings.TrimSpace(data.Telephone.Text),
+			VisitHours: data.VisitHour.Header,
+			WebLink:    data.WebLink.Href,
+			PictureURL: person.InfoBlock.Picture.WebLink.Href,
+		})
+	}
+	markMainContacts(res, c.mainContactRoles)
+	return res
+}
@@ -0,0 +1,258 @@
+package campusonline
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+func TestGetXCalOrg(t *testing.T) {
+	cal := fixtureCalendar(t)
+	if got := len(cal.Vcalendar.Events); got != 20 {
+		t.Fatalf("expected 20 events, got %d", got)
+	}
+	if cal.Vcalendar.Prodid == "" {
+		t.Error("expected calendar header to be decoded")
+	}
+	first := cal.Vcalendar.Events[0]
+	if first.Uid != "884100001@tumonline" || first.Dtstart != "20211019T083000" || first.Status != "fix" {
+		t.Errorf("unexpected first event %+v", first)
+	}
+}
+
+func TestGetXCalOrgDateRange(t *testing.T) {
+	c, server := newTestClient(t)
+	cal, err := c.GetXCalOrg(time.Date(2021, 10, 18, 12, 0, 0, 0, time.Local), time.Date(2021, 10, 19, 0, 0, 0, 0, time.Local), CsOrgId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(cal.Vcalendar.Events); got != 4 {
+		t.Errorf("expected 4 events on the 18th and 19th, got %d", got)
+	}
+	requests := server.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0], "fromDate=20211018&untilDate=20211019") {
+		t.Errorf("unexpected requests %v", requests)
+	}
+}
+
+func TestGetXCalOrgChunked(t *testing.T) {
+	c, server := newTestClient(t, WithChunkSize(time.Hour*24*7), WithMaxConcurrency(3))
+	cal, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(cal.Vcalendar.Events); got != 20 {
+		t.Errorf("expected 20 events, got %d", got)
+	}
+	if got := len(server.Requests()); got != 26 {
+		t.Errorf("expected 26 weekly requests, got %d", got)
+	}
+	uids := map[string]bool{}
+	for _, event := range cal.Vcalendar.Events {
+		if uids[event.Uid] {
+			t.Errorf("duplicate event %s", event.Uid)
+		}
+		uids[event.Uid] = true
+	}
+}
+
+func TestGetXCalOrgErrors(t *testing.T) {
+	c, server := newTestClient(t, WithChunkSize(time.Hour*24*30))
+	server.Fail(campusonlinetest.EndpointXCalOrg, http.StatusServiceUnavailable)
+	_, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status error 503, got %v", err)
+	}
+
+	server.Fail(campusonlinetest.EndpointXCalOrg, 0)
+	server.SetTokens("rotated", "rotated")
+	_, err = c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId)
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status error 401, got %v", err)
+	}
+}
+
+func TestGetXCalOrgTimeout(t *testing.T) {
+	c, server := newTestClient(t, WithHTTPClient(&http.Client{Timeout: time.Millisecond * 50}))
+	server.SetLatency(time.Millisecond * 200)
+	if _, err := c.GetXCalOrg(semesterStart, semesterEnd, CsOrgId); err == nil {
+		t.Error("expected timeout error")
+	}
+}
+
+func TestDateChunks(t *testing.T) {
+	from := time.Date(2021, 10, 1, 12, 0, 0, 0, time.Local)
+	until := time.Date(2021, 10, 10, 8, 0, 0, 0, time.Local)
+	tests := []struct {
+		size     time.Duration
+		expected []string
+	}{
+		{0, []string{"20211001-20211010"}},
+		{time.Hour * 24 * 7, []string{"20211001-20211007", "20211008-20211010"}},
+		{time.Hour * 24 * 5, []string{"20211001-20211005", "20211006-20211010"}},
+		{time.Hour, []string{"20211001-20211001", "20211002-20211002", "20211003-20211003", "20211004-20211004",
+			"20211005-20211005", "20211006-20211006", "20211007-20211007", "20211008-20211008", "20211009-20211009",
+			"20211010-20211010"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, chunk := range dateChunks(from, until, test.size) {
+			got = append(got, chunk.from.Format("20060102")+"-"+chunk.until.Format("20060102"))
+		}
+		if strings.Join(got, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("dateChunks(%v): expected %v, got %v", test.size, test.expected, got)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	cal := fixtureCalendar(t)
+	cal.Sort()
+	if !sort.IsSorted(cal.Vcalendar.Events) {
+		t.Fatal("events are not sorted")
+	}
+	if first := cal.Vcalendar.Events[0]; first.Dtstart != "20211018T100000" {
+		t.Errorf("expected first event on monday the 18th at 10:00, got %s", first.Dtstart)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	cal := fixtureCalendar(t)
+	cal.Filter()
+	if got := len(cal.Vcalendar.Events); got != 17 {
+		t.Fatalf("expected 17 events after filtering, got %d", got)
+	}
+	for _, event := range cal.Vcalendar.Events {
+		switch {
+		case event.Status != "fix" && event.Status != "geplant":
+			t.Errorf("event %s with status %s was not filtered", event.Uid, event.Status)
+		case strings.Contains(event.Comment, "Videoübertragung"):
+			t.Errorf("video transmission %s was not filtered", event.Uid)
+		case strings.HasPrefix(event.Summary, "0"):
+			t.Errorf("leading digits of %q were not removed", event.Summary)
+		}
+		if _, known := map[string]bool{"MI HS1": true, "MI HS2": true, "MI HS3": true, "Interims I 102": true}[event.Location.Text]; !known {
+			t.Errorf("unexpected location %q", event.Location.Text)
+		}
+		if strings.Contains(event.Summary, "Praktikum Systemadministration") && event.Location.Text != "Interims I 102" {
+			t.Errorf("expected Praktikum Systemadministration in Interims I 102, got %q", event.Location.Text)
+		}
+	}
+}
+
+func TestFilterEnglish(t *testing.T) {
+	var cal ICalendar
+	location := "5602.EG.001, Hörsaal 1"
+	for _, e := range []struct{ status, comment string }{
+		{"fixed", ""},
+		{"planned", ""},
+		{"cancelled", ""},
+		{"fixed", "Video transmission from MI HS1"},
+	} {
+		event := VEvent{Status: e.status, Comment: e.comment}
+		event.Location.Text = location
+		cal.Vcalendar.Events = append(cal.Vcalendar.Events, event)
+	}
+	cal.Filter()
+	if got := len(cal.Vcalendar.Events); got != 2 {
+		t.Errorf("expected the fixed and the planned event, got %d", got)
+	}
+}
+
+func TestGroupByCourse(t *testing.T) {
+	cal := fixtureCalendar(t)
+	cal.Filter()
+	cal.Sort()
+	courses := cal.GroupByCourse()
+	if len(courses) != 4 {
+		t.Fatalf("expected 4 courses, got %d", len(courses))
+	}
+	expected := []struct {
+		id     int
+		title  string
+		slug   string
+		events int
+	}{
+		{950000001, "Einführung in die Informatik", "EidI", 6},
+		{950000002, "Diskrete Strukturen", "DS", 5},
+		{950000003, "Praktikum Systemadministration", "PS", 3},
+		{950000004, "Einführung in die Informatik (Übung)", "EidI1", 2},
+	}
+	for _, e := range expected {
+		course, found := findCourse(courses, e.id)
+		if !found {
+			t.Errorf("course %d missing", e.id)
+			continue
+		}
+		if course.Title != e.title || course.Slug != e.slug || len(course.Events) != e.events {
+			t.Errorf("course %d: expected %q (%s) with %d events, got %q (%s) with %d events",
+				e.id, e.title, e.slug, e.events, course.Title, course.Slug, len(course.Events))
+		}
+	}
+	course, _ := findCourse(courses, 950000002)
+	if course.Events[0].RoomName != "MI HS2" || course.Events[0].End.Sub(course.Events[0].Start) != time.Hour*2 {
+		t.Errorf("unexpected event %+v", course.Events[0])
+	}
+}
+
+func TestGroupByCourseEventIDs(t *testing.T) {
+	cal := fixtureCalendar(t)
+	cal.Sort()
+	uids := map[string]bool{}
+	for _, event := range cal.Vcalendar.Events {
+		uids[event.Uid] = true
+	}
+	for _, course := range cal.GroupByCourse() {
+		// the first event creates the course and must carry its id like the later ones
+		for i, event := range course.Events {
+			if !uids[event.EventID+"@tumonline"] {
+				t.Errorf("course %d: expected the uid without host as id of event %d, got %q", course.CourseID, i, event.EventID)
+			}
+		}
+	}
+}
+
+func TestLoadCourseContacts(t *testing.T) {
+	c, _ := newTestClient(t)
+	courses, err := c.LoadCourseContacts([]Course{{CourseID: 950000001}, {CourseID: 950000002}, {CourseID: 950000003}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMain := map[int]string{950000001: "Seidl", 950000002: "Esparza", 950000003: "Pruefer"}
+	for _, course := range courses {
+		if len(course.Contacts) != 2 {
+			t.Errorf("course %d: expected 2 contacts, got %d", course.CourseID, len(course.Contacts))
+		}
+		mainContacts := 0
+		for _, contact := range course.Contacts {
+			if contact.MainContact {
+				mainContacts++
+				if contact.LastName != expectedMain[course.CourseID] {
+					t.Errorf("course %d: expected main contact %s, got %s", course.CourseID, expectedMain[course.CourseID], contact.LastName)
+				}
+			}
+		}
+		if mainContacts != 1 {
+			t.Errorf("course %d: expected one main contact, got %d", course.CourseID, mainContacts)
+		}
+	}
+	seidl := courses[0].Contacts[1]
+	if seidl.FirstName != "Helmut" || seidl.Email != "seidl@in.tum.de" || seidl.Role != "Leiter/in, Prüfer/in" {
+		t.Errorf("unexpected contact %+v", seidl)
+	}
+}
+
+func TestLoadCourseContactsError(t *testing.T) {
+	c, _ := newTestClient(t)
+	_, err := c.LoadCourseContacts([]Course{{CourseID: 950000001}, {CourseID: 1}})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status error 404 for unknown course, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package campusonline
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// XCalDecoder reads the events of an xCal document one at a time, so only a single event is held in memory
+type XCalDecoder struct {
+	d *xml.Decoder
+}
+
+// NewXCalDecoder returns a decoder reading an xCal document from r
+func NewXCalDecoder(r io.Reader) *XCalDecoder {
+	return &XCalDecoder{d: xml.NewDecoder(r)}
+}
+
+// Next returns the next vevent of the document. It returns io.EOF once all events were read.
+func (d *XCalDecoder) Next() (VEvent, error) {
+	for {
+		tok, err := d.d.Token()
+		if err != nil {
+			return VEvent{}, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "vevent" {
+			continue
+		}
+		var event VEvent
+		if err := d.d.DecodeElement(&event, &start); err != nil {
+			return VEvent{}, err
+		}
+		return event, nil
+	}
+}
+
+// StreamXCalOrg calls fn for every event in the specified time stamp for the organization while the response is
+// still being read. Chunks configured with WithChunkSize are streamed one after another.
+// Streaming stops at the first error returned by fn.
+func (c *CampusOnline) StreamXCalOrg(from time.Time, until time.Time, orgID int, fn func(VEvent) error) error {
+	// events spanning midnight show up in two adjacent chunks. Remembering the uids of the previous chunk is
+	// enough to drop them without keeping every uid in memory.
+	var prevUids map[string]bool
+	for _, chunk := range dateChunks(from, until, c.chunkSize) {
+		uids := map[string]bool{}
+		err := c.streamXCalOrgChunk(chunk.from, chunk.until, orgID, func(event VEvent) error {
+			if event.Uid != "" {
+				if prevUids[event.Uid] || uids[event.Uid] {
+					return nil
+				}
+				uids[event.Uid] = true
+			}
+			return fn(event)
+		})
+		if err != nil {
+			return err
+		}
+		prevUids = uids
+	}
+	return nil
+}
+
+// StreamXCalOrgChan is like StreamXCalOrg but delivers the events through a channel.
+// Both channels are closed once the response was read; at most one error is sent. The events channel must be
+// drained, otherwise the request is never finished.
+func (c *CampusOnline) StreamXCalOrgChan(from time.Time, until time.Time, orgID int) (<-chan VEvent, <-chan error) {
+	events := make(chan VEvent)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(events)
+		err := c.StreamXCalOrg(from, until, orgID, func(event VEvent) error {
+			events <- event
+			return nil
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return events, errs
+}
+
+func (c *CampusOnline) streamXCalOrgChunk(from time.Time, until time.Time, orgID int, fn func(VEvent) error) error {
+	stream, err := c.getStream(xCalOrgURL(from, until, orgID))
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	d := NewXCalDecoder(stream)
+	for {
+		event, err := d.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package campusonline
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RBG-TUM/CAMPUSOnline/campusonlinetest"
+)
+
+func TestXCalDecoder(t *testing.T) {
+	d := NewXCalDecoder(strings.NewReader(string(campusonlinetest.Fixture("xcal_53598.xml"))))
+	count := 0
+	for {
+		event, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Uid == "" || event.Dtstart == "" {
+			t.Errorf("incomplete event %+v", event)
+		}
+		count++
+	}
+	if count != 20 {
+		t.Errorf("expected 20 events, got %d", count)
+	}
+}
+
+func TestStreamXCalOrg(t *testing.T) {
+	c, _ := newTestClient(t, WithChunkSize(time.Hour*24*14))
+	uids := map[string]bool{}
+	err := c.StreamXCalOrg(semesterStart, semesterEnd, CsOrgId, func(event VEvent) error {
+		if uids[event.Uid] {
+			t.Errorf("duplicate event %s", event.Uid)
+		}
+		uids[event.Uid] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uids) != 20 {
+		t.Errorf("expected 20 events, got %d", len(uids))
+	}
+}
+
+func TestStreamXCalOrgStops(t *testing.T) {
+	c, _ := newTestClient(t)
+	stop := errors.New("stop")
+	count := 0
+	err := c.StreamXCalOrg(semesterStart, semesterEnd, CsOrgId, func(event VEvent) error {
+		count++
+		if count == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 3 {
+		t.Errorf("expected streaming to stop after 3 events, got %d events and error %v", count, err)
+	}
+}
+
+func TestStreamXCalOrgChan(t *testing.T) {
+	c, _ := newTestClient(t)
+	events, errs := c.StreamXCalOrgChan(semesterStart, semesterEnd, CsOrgId)
+	count := 0
+	for range events {
+		count++
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if count != 20 {
+		t.Errorf("expected 20 events, got %d", count)
+	}
+}